@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+)
+
+// runCommand runs a one-off maintenance subcommand instead of the server
+func runCommand(name string) {
+	switch name {
+	case "migrate-images":
+		migrateImages()
+	default:
+		log.Fatalf("Unknown command %q (available: migrate-images)", name)
+	}
+}
+
+// migrateImages converts legacy base64 item images to storage objects. Interrupting it
+// (Ctrl+C) saves a checkpoint; running it again resumes from there.
+func migrateImages() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	migrationService := services.NewImageMigrationService()
+
+	migration, err := migrationService.Start(ctx)
+	if err != nil {
+		log.Fatal("Failed to start image migration:", err)
+	}
+
+	if migration.Cursor != nil {
+		fmt.Printf("Resuming image migration %s after item %s\n", migration.ID, *migration.Cursor)
+	} else {
+		fmt.Printf("Starting image migration %s\n", migration.ID)
+	}
+
+	err = migrationService.Run(ctx, migration, func(m *models.ImageMigration) {
+		fmt.Printf("  scanned %d items, updated %d, converted %d images, %d failed\n",
+			m.ItemsScanned, m.ItemsUpdated, m.ImagesConverted, m.ImagesFailed)
+	})
+	if err != nil {
+		log.Fatal("Image migration stopped: ", err, " (run again to resume)")
+	}
+
+	status, err := migrationService.GetStatus(context.Background())
+	if err == nil && len(status.Failures) > 0 {
+		fmt.Printf("%d images could not be converted:\n", len(status.Failures))
+		for _, f := range status.Failures {
+			fmt.Printf("  item %s image %d: %s\n", f.ItemID, f.ImageIndex, f.Error)
+		}
+	}
+
+	fmt.Println("Image migration completed")
+}
@@ -20,6 +20,58 @@ type Config struct {
 	PESUAuthURL         string
 	RateLimitMax        int
 	RateLimitWindow     int
+	MessageRulesFile    string
+
+	// Messaging anti-spam limits, keyed on the authenticated user
+	NewConversationsPerDay           int
+	NewAccountNewConversationsPerDay int
+	MessagesPerMinutePerConversation int
+	NewAccountSlowModeSeconds        int
+	NewAccountAgeHours               int
+
+	// Object storage backend: "supabase", "local" or "s3"
+	StorageBackend     string
+	StorageLocalDir    string
+	StoragePublicURL   string // Base URL the app serves local storage from
+	S3Endpoint         string
+	S3Region           string
+	S3AccessKeyID      string
+	S3SecretAccessKey  string
+	S3PublicURL        string // Optional CDN/public base URL for S3 objects
+	S3ForcePathStyle   bool
+
+	// Comma-separated user IDs allowed to use admin endpoints
+	AdminUserIDs string
+
+	// Orphaned upload garbage collection
+	UploadGCIntervalMinutes int
+	UploadGCGraceHours      int
+
+	// Reject uploads whose perceptual hash exactly matches a banned image
+	BlockBannedImages bool
+
+	// Per-user image storage quotas by seller tier; 0 means unlimited
+	StorageQuotaMB             int
+	StorageQuotaImages         int
+	VerifiedStorageQuotaMB     int
+	VerifiedStorageQuotaImages int
+	TrustedStorageQuotaMB      int
+	TrustedStorageQuotaImages  int
+
+	// @handles: reserved/blocked words, how often a handle can change and how long old handles redirect
+	HandleRulesFile          string
+	HandleChangeCooldownDays int
+	HandleRedirectDays       int
+
+	// Account deletion: how long a deletion request can be cancelled and how often due accounts are purged
+	AccountDeletionGraceDays       int
+	AccountDeletionIntervalMinutes int
+
+	// How often vacations past their return date are ended
+	VacationCheckIntervalMinutes int
+
+	// Most items a graduated (alumni) account can have listed at once; 0 stops alumni listing
+	AlumniMaxActiveListings int
 }
 
 func Load() *Config {
@@ -29,6 +81,25 @@ func Load() *Config {
 
 	rateLimitMax, _ := strconv.Atoi(getEnv("RATE_LIMIT_MAX", "100"))
 	rateLimitWindow, _ := strconv.Atoi(getEnv("RATE_LIMIT_WINDOW", "3600"))
+	newConversationsPerDay, _ := strconv.Atoi(getEnv("NEW_CONVERSATIONS_PER_DAY", "20"))
+	newAccountNewConversationsPerDay, _ := strconv.Atoi(getEnv("NEW_ACCOUNT_NEW_CONVERSATIONS_PER_DAY", "5"))
+	messagesPerMinutePerConversation, _ := strconv.Atoi(getEnv("MESSAGES_PER_MINUTE_PER_CONVERSATION", "10"))
+	newAccountSlowModeSeconds, _ := strconv.Atoi(getEnv("NEW_ACCOUNT_SLOW_MODE_SECONDS", "30"))
+	newAccountAgeHours, _ := strconv.Atoi(getEnv("NEW_ACCOUNT_AGE_HOURS", "24"))
+	uploadGCIntervalMinutes, _ := strconv.Atoi(getEnv("UPLOAD_GC_INTERVAL_MINUTES", "60"))
+	uploadGCGraceHours, _ := strconv.Atoi(getEnv("UPLOAD_GC_GRACE_HOURS", "24"))
+	storageQuotaMB, _ := strconv.Atoi(getEnv("STORAGE_QUOTA_MB", "50"))
+	storageQuotaImages, _ := strconv.Atoi(getEnv("STORAGE_QUOTA_IMAGES", "100"))
+	verifiedStorageQuotaMB, _ := strconv.Atoi(getEnv("VERIFIED_STORAGE_QUOTA_MB", "250"))
+	verifiedStorageQuotaImages, _ := strconv.Atoi(getEnv("VERIFIED_STORAGE_QUOTA_IMAGES", "500"))
+	trustedStorageQuotaMB, _ := strconv.Atoi(getEnv("TRUSTED_STORAGE_QUOTA_MB", "1024"))
+	trustedStorageQuotaImages, _ := strconv.Atoi(getEnv("TRUSTED_STORAGE_QUOTA_IMAGES", "2000"))
+	handleChangeCooldownDays, _ := strconv.Atoi(getEnv("HANDLE_CHANGE_COOLDOWN_DAYS", "30"))
+	handleRedirectDays, _ := strconv.Atoi(getEnv("HANDLE_REDIRECT_DAYS", "30"))
+	accountDeletionGraceDays, _ := strconv.Atoi(getEnv("ACCOUNT_DELETION_GRACE_DAYS", "14"))
+	accountDeletionIntervalMinutes, _ := strconv.Atoi(getEnv("ACCOUNT_DELETION_INTERVAL_MINUTES", "60"))
+	vacationCheckIntervalMinutes, _ := strconv.Atoi(getEnv("VACATION_CHECK_INTERVAL_MINUTES", "15"))
+	alumniMaxActiveListings, _ := strconv.Atoi(getEnv("ALUMNI_MAX_ACTIVE_LISTINGS", "3"))
 
 	// Validate required environment variables
 	jwtSecret := getEnv("JWT_SECRET", "")
@@ -45,8 +116,10 @@ func Load() *Config {
 		log.Fatal("SUPABASE_URL and SUPABASE_ANON_KEY environment variables are required")
 	}
 
+	port := getEnv("PORT", "8080")
+
 	return &Config{
-		Port:                getEnv("PORT", "8080"),
+		Port:                port,
 		SupabaseURL:         supabaseURL,
 		SupabaseAnonKey:     supabaseAnonKey,
 		SupabaseServiceKey:  getEnv("SUPABASE_SERVICE_KEY", ""),
@@ -56,6 +129,48 @@ func Load() *Config {
 		PESUAuthURL:         getEnv("PESU_AUTH_URL", "https://pesu-auth.onrender.com"),
 		RateLimitMax:        rateLimitMax,
 		RateLimitWindow:     rateLimitWindow,
+		MessageRulesFile:    getEnv("MESSAGE_RULES_FILE", "config/message_rules.json"),
+
+		NewConversationsPerDay:           newConversationsPerDay,
+		NewAccountNewConversationsPerDay: newAccountNewConversationsPerDay,
+		MessagesPerMinutePerConversation: messagesPerMinutePerConversation,
+		NewAccountSlowModeSeconds:        newAccountSlowModeSeconds,
+		NewAccountAgeHours:               newAccountAgeHours,
+
+		StorageBackend:     strings.ToLower(getEnv("STORAGE_BACKEND", "supabase")),
+		StorageLocalDir:    getEnv("STORAGE_LOCAL_DIR", "./data/storage"),
+		StoragePublicURL:   strings.TrimRight(getEnv("STORAGE_PUBLIC_URL", "http://localhost:"+port), "/"),
+		S3Endpoint:         strings.TrimRight(getEnv("S3_ENDPOINT", ""), "/"),
+		S3Region:           getEnv("S3_REGION", "us-east-1"),
+		S3AccessKeyID:      getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey:  getEnv("S3_SECRET_ACCESS_KEY", ""),
+		S3PublicURL:        strings.TrimRight(getEnv("S3_PUBLIC_URL", ""), "/"),
+		S3ForcePathStyle:   getEnv("S3_FORCE_PATH_STYLE", "true") == "true",
+
+		AdminUserIDs: getEnv("ADMIN_USER_IDS", ""),
+
+		UploadGCIntervalMinutes: uploadGCIntervalMinutes,
+		UploadGCGraceHours:      uploadGCGraceHours,
+
+		BlockBannedImages: getEnv("BLOCK_BANNED_IMAGES", "true") == "true",
+
+		StorageQuotaMB:             storageQuotaMB,
+		StorageQuotaImages:         storageQuotaImages,
+		VerifiedStorageQuotaMB:     verifiedStorageQuotaMB,
+		VerifiedStorageQuotaImages: verifiedStorageQuotaImages,
+		TrustedStorageQuotaMB:      trustedStorageQuotaMB,
+		TrustedStorageQuotaImages:  trustedStorageQuotaImages,
+
+		HandleRulesFile:          getEnv("HANDLE_RULES_FILE", "config/handle_rules.json"),
+		HandleChangeCooldownDays: handleChangeCooldownDays,
+		HandleRedirectDays:       handleRedirectDays,
+
+		AccountDeletionGraceDays:       accountDeletionGraceDays,
+		AccountDeletionIntervalMinutes: accountDeletionIntervalMinutes,
+
+		VacationCheckIntervalMinutes: vacationCheckIntervalMinutes,
+
+		AlumniMaxActiveListings: alumniMaxActiveListings,
 	}
 }
 
@@ -67,6 +182,19 @@ func (c *Config) IsProduction() bool {
 	return strings.ToLower(c.Environment) == "production"
 }
 
+// IsAdmin reports whether userID is listed in ADMIN_USER_IDS
+func (c *Config) IsAdmin(userID string) bool {
+	if userID == "" {
+		return false
+	}
+	for _, id := range strings.Split(c.AdminUserIDs, ",") {
+		if strings.TrimSpace(id) == userID {
+			return true
+		}
+	}
+	return false
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -0,0 +1,11 @@
+-- User blocks: a blocker no longer receives messages from, or sees listings of, the blocked user
+create table if not exists user_blocks (
+    id          uuid primary key default gen_random_uuid(),
+    blocker_id  uuid not null references user_profiles(id) on delete cascade,
+    blocked_id  uuid not null references user_profiles(id) on delete cascade,
+    created_at  timestamptz not null default now(),
+    unique (blocker_id, blocked_id),
+    check (blocker_id <> blocked_id)
+);
+
+create index if not exists user_blocks_blocked_id_idx on user_blocks (blocked_id);
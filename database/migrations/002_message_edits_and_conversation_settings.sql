@@ -0,0 +1,25 @@
+-- Edit, unsend and delete-for-me support on messages
+alter table messages add column if not exists edited_at timestamptz;
+alter table messages add column if not exists unsent_at timestamptz;
+alter table messages add column if not exists sender_deleted boolean not null default false;
+alter table messages add column if not exists receiver_deleted boolean not null default false;
+
+-- Previous versions of edited messages
+create table if not exists message_edits (
+    id                uuid primary key default gen_random_uuid(),
+    message_id        uuid not null references messages(id) on delete cascade,
+    previous_message  text not null,
+    edited_at         timestamptz not null default now()
+);
+
+create index if not exists message_edits_message_id_idx on message_edits (message_id);
+
+-- Per-user archive and mute state for a conversation with another user
+create table if not exists conversation_settings (
+    user_id        uuid not null references user_profiles(id) on delete cascade,
+    other_user_id  uuid not null references user_profiles(id) on delete cascade,
+    archived_at    timestamptz,
+    muted          boolean not null default false,
+    updated_at     timestamptz not null default now(),
+    primary key (user_id, other_user_id)
+);
@@ -0,0 +1,17 @@
+-- Warning shown to the recipient when a message trips a "warn" screening rule
+alter table messages add column if not exists warning text;
+
+-- Screening rule hits, kept for moderation
+create table if not exists message_screening_hits (
+    id            uuid primary key default gen_random_uuid(),
+    message_id    uuid references messages(id) on delete set null,
+    sender_id     uuid not null references user_profiles(id) on delete cascade,
+    receiver_id   uuid not null references user_profiles(id) on delete cascade,
+    rule_id       text not null,
+    action        text not null,
+    matched_text  text not null,
+    created_at    timestamptz not null default now()
+);
+
+create index if not exists message_screening_hits_sender_id_idx on message_screening_hits (sender_id);
+create index if not exists message_screening_hits_created_at_idx on message_screening_hits (created_at);
@@ -0,0 +1,18 @@
+-- Direct-to-storage uploads. A row is created when a signed upload URL is issued
+-- and completed once the object has been verified and processed.
+create table if not exists uploads (
+    id            uuid primary key,
+    user_id       uuid not null references user_profiles(id) on delete cascade,
+    bucket        text not null,
+    object_key    text not null,
+    content_type  text not null,
+    size          bigint not null,
+    status        text not null default 'pending', -- pending | completed | failed
+    url           text,                            -- Full variant URL, set on completion
+    expires_at    timestamptz not null,
+    completed_at  timestamptz,
+    created_at    timestamptz not null default now()
+);
+
+create index if not exists uploads_user_id_idx on uploads (user_id);
+create index if not exists uploads_status_created_at_idx on uploads (status, created_at);
@@ -0,0 +1,27 @@
+-- Runs of the legacy base64 image migration. The cursor is the last item ID processed,
+-- so an interrupted run resumes where it stopped.
+create table if not exists image_migrations (
+    id                uuid primary key,
+    status            text not null default 'running', -- running | completed | failed
+    cursor            uuid,
+    items_scanned     integer not null default 0,
+    items_updated     integer not null default 0,
+    images_converted  integer not null default 0,
+    images_failed     integer not null default 0,
+    last_error        text,
+    started_at        timestamptz not null default now(),
+    updated_at        timestamptz not null default now(),
+    completed_at      timestamptz
+);
+
+-- Images that could not be converted; the item keeps the original base64 data
+create table if not exists image_migration_failures (
+    id            uuid primary key default gen_random_uuid(),
+    migration_id  uuid not null references image_migrations(id) on delete cascade,
+    item_id       uuid not null,
+    image_index   integer not null,
+    error         text not null,
+    created_at    timestamptz not null default now()
+);
+
+create index if not exists image_migration_failures_migration_id_idx on image_migration_failures (migration_id);
@@ -0,0 +1,34 @@
+-- Perceptual hash of each uploaded image (16 hex digits) and its position-tagged bytes,
+-- used to find near-duplicate candidates with an overlap query
+alter table uploads add column if not exists phash text;
+alter table uploads add column if not exists phash_bands integer[];
+
+create index if not exists uploads_phash_bands_idx on uploads using gin (phash_bands);
+
+-- Listings whose images are near-duplicates of another seller's uploads
+create table if not exists image_moderation_flags (
+    id                 uuid primary key,
+    item_id            uuid not null references items(id) on delete cascade,
+    seller_id          uuid not null references user_profiles(id) on delete cascade,
+    image_url          text not null,
+    phash              text not null,
+    matched_upload_id  uuid references uploads(id) on delete set null,
+    matched_user_id    uuid references user_profiles(id) on delete set null,
+    matched_url        text,
+    distance           integer not null,
+    status             text not null default 'open', -- open | dismissed | banned
+    reviewed_by        uuid references user_profiles(id) on delete set null,
+    reviewed_at        timestamptz,
+    created_at         timestamptz not null default now(),
+    unique (item_id, image_url, matched_upload_id)
+);
+
+create index if not exists image_moderation_flags_status_idx on image_moderation_flags (status, created_at);
+
+-- Images moderators have banned; exact re-uploads can be rejected
+create table if not exists banned_image_hashes (
+    phash       text primary key,
+    reason      text,
+    banned_by   uuid references user_profiles(id) on delete set null,
+    created_at  timestamptz not null default now()
+);
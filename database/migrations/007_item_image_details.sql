@@ -0,0 +1,8 @@
+-- Dimensions and BlurHash placeholder of each processed upload
+alter table uploads add column if not exists width integer;
+alter table uploads add column if not exists height integer;
+alter table uploads add column if not exists blurhash text;
+
+-- Structured item images (order, caption, variants, dimensions). items.images is kept in
+-- sync with the image URLs in order for older clients.
+alter table items add column if not exists image_details jsonb;
@@ -0,0 +1,8 @@
+-- Bytes actually stored for an upload (all processed variants). Quota usage falls back
+-- to the upload size for rows recorded before this column existed.
+alter table uploads add column if not exists stored_bytes bigint not null default 0;
+
+-- Sellers an admin has marked trusted get the highest storage quota
+alter table user_profiles add column if not exists trusted_seller boolean not null default false;
+
+create index if not exists uploads_user_id_status_idx on uploads (user_id, status);
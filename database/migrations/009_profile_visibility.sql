@@ -0,0 +1,4 @@
+-- Per-field visibility of optional public profile fields, e.g.
+-- {"show_name": true, "show_branch": false, "show_semester": false, "show_campus": false}.
+-- Null means the defaults: name shown, everything else hidden.
+alter table user_profiles add column if not exists profile_visibility jsonb;
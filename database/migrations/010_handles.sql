@@ -0,0 +1,15 @@
+-- Unique @handles. Handles are stored lowercase, so the unique index is case-insensitive.
+alter table user_profiles add column if not exists handle text;
+alter table user_profiles add column if not exists handle_changed_at timestamptz;
+
+create unique index if not exists user_profiles_handle_idx on user_profiles (handle);
+
+-- Previous handles keep resolving to (and stay reserved for) their user until expires_at
+create table if not exists handle_redirects (
+    handle      text primary key,
+    user_id     uuid not null references user_profiles(id) on delete cascade,
+    expires_at  timestamptz not null,
+    created_at  timestamptz not null default now()
+);
+
+create index if not exists handle_redirects_user_id_idx on handle_redirects (user_id);
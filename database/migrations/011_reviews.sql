@@ -0,0 +1,25 @@
+-- Who an item was sold to, set when the seller marks it sold
+alter table items add column if not exists buyer_id uuid references user_profiles(id) on delete set null;
+alter table items add column if not exists sold_at timestamptz;
+
+create index if not exists items_buyer_id_idx on items (buyer_id);
+
+-- Reviews left by the buyer and seller of a sold item, one each
+create table if not exists reviews (
+    id             uuid primary key,
+    item_id        uuid not null references items(id) on delete cascade,
+    reviewer_id    uuid not null references user_profiles(id) on delete cascade,
+    reviewee_id    uuid not null references user_profiles(id) on delete cascade,
+    reviewer_role  text not null check (reviewer_role in ('buyer', 'seller')),
+    rating         smallint not null check (rating between 1 and 5),
+    text           text not null default '',
+    reply          text,
+    replied_at     timestamptz,
+    created_at     timestamptz not null default now(),
+    unique (item_id, reviewer_id)
+);
+
+create index if not exists reviews_reviewee_id_idx on reviews (reviewee_id, created_at desc);
+
+-- Number of reviews behind user_profiles.rating
+alter table user_profiles add column if not exists review_count integer not null default 0;
@@ -0,0 +1,42 @@
+-- Sales of items, created when the seller marks an item sold and confirmed by the buyer.
+-- items.buyer_id/sold_at mirror the item's active transaction.
+create table if not exists transactions (
+    id              uuid primary key,
+    item_id         uuid not null references items(id) on delete cascade,
+    seller_id       uuid not null references user_profiles(id) on delete cascade,
+    buyer_id        uuid not null references user_profiles(id) on delete cascade,
+    status          text not null default 'pending'
+                    check (status in ('pending', 'completed', 'cancelled', 'disputed')),
+    final_price     numeric(10, 2) not null check (final_price > 0),
+    handover_at     timestamptz,
+    confirmed_at    timestamptz,
+    cancelled_at    timestamptz,
+    cancelled_by    uuid references user_profiles(id) on delete set null,
+    disputed_at     timestamptz,
+    disputed_by     uuid references user_profiles(id) on delete set null,
+    dispute_reason  text not null default '',
+    created_at      timestamptz not null default now(),
+    updated_at      timestamptz not null default now()
+);
+
+-- An item can only be in one live sale at a time
+create unique index if not exists transactions_active_item_idx
+    on transactions (item_id) where status <> 'cancelled';
+
+create index if not exists transactions_seller_id_idx on transactions (seller_id, created_at desc);
+create index if not exists transactions_buyer_id_idx on transactions (buyer_id, created_at desc);
+
+-- Items marked sold before transactions existed count as completed sales
+insert into transactions (id, item_id, seller_id, buyer_id, status, final_price, confirmed_at, created_at, updated_at)
+select gen_random_uuid(), id, seller_id, buyer_id, 'completed', price, sold_at, sold_at, sold_at
+from items
+where buyer_id is not null
+on conflict do nothing;
+
+-- Reviews now belong to a completed transaction
+alter table reviews add column if not exists transaction_id uuid references transactions(id) on delete set null;
+
+update reviews r
+set transaction_id = t.id
+from transactions t
+where r.transaction_id is null and t.item_id = r.item_id and t.status = 'completed';
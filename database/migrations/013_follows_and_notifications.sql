@@ -0,0 +1,24 @@
+-- Users following sellers to see their new listings
+create table if not exists user_follows (
+    follower_id  uuid not null references user_profiles(id) on delete cascade,
+    followee_id  uuid not null references user_profiles(id) on delete cascade,
+    created_at   timestamptz not null default now(),
+    primary key (follower_id, followee_id),
+    check (follower_id <> followee_id)
+);
+
+create index if not exists user_follows_followee_id_idx on user_follows (followee_id);
+
+-- In-app notifications, e.g. a followed seller posting a new listing
+create table if not exists notifications (
+    id          uuid primary key,
+    user_id     uuid not null references user_profiles(id) on delete cascade,
+    type        text not null,
+    actor_id    uuid references user_profiles(id) on delete cascade,
+    item_id     uuid references items(id) on delete cascade,
+    message     text not null default '',
+    read_at     timestamptz,
+    created_at  timestamptz not null default now()
+);
+
+create index if not exists notifications_user_id_idx on notifications (user_id, created_at desc);
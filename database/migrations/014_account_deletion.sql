@@ -0,0 +1,8 @@
+-- Account deletion requests. Accounts are anonymised once deletion_scheduled_for passes;
+-- deleted_at marks rows that have been anonymised.
+alter table user_profiles add column if not exists deletion_requested_at timestamptz;
+alter table user_profiles add column if not exists deletion_scheduled_for timestamptz;
+alter table user_profiles add column if not exists deleted_at timestamptz;
+
+create index if not exists user_profiles_deletion_scheduled_for_idx
+    on user_profiles (deletion_scheduled_for) where deletion_scheduled_for is not null;
@@ -0,0 +1,20 @@
+-- Vacation mode: sellers can hide their listings while away, optionally until a return date
+alter table user_profiles add column if not exists vacation_started_at timestamptz;
+alter table user_profiles add column if not exists vacation_until timestamptz;
+alter table user_profiles add column if not exists vacation_message text not null default '';
+
+create index if not exists user_profiles_vacation_until_idx
+    on user_profiles (vacation_until) where vacation_until is not null;
+
+-- Mirrors the seller's vacation state so listings can be filtered without a join
+alter table items add column if not exists seller_away boolean not null default false;
+
+create index if not exists items_seller_away_idx on items (seller_away) where seller_away;
+
+-- Conversations that have had an away auto-reply during the user's current vacation
+create table if not exists vacation_auto_replies (
+    user_id        uuid not null references user_profiles(id) on delete cascade,
+    other_user_id  uuid not null references user_profiles(id) on delete cascade,
+    created_at     timestamptz not null default now(),
+    primary key (user_id, other_user_id)
+);
@@ -0,0 +1,15 @@
+-- Graduated students become alumni, who can keep selling with a cap on active listings
+alter table user_profiles add column if not exists alumni_since timestamptz;
+
+-- Audit trail of changes to PESU-sourced profile fields, including SRN changes linked by PRN
+create table if not exists user_profile_history (
+    id          uuid primary key,
+    user_id     uuid not null references user_profiles(id) on delete cascade,
+    field       text not null,
+    old_value   text not null default '',
+    new_value   text not null default '',
+    created_at  timestamptz not null default now()
+);
+
+create index if not exists user_profile_history_user_id_idx on user_profile_history (user_id, created_at desc);
+create index if not exists user_profiles_prn_idx on user_profiles (prn);
@@ -9,7 +9,9 @@ require (
 	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
+	github.com/supabase-community/postgrest-go v0.0.11-0.20240521132850-9413d68fbc6d
 	github.com/supabase-community/supabase-go v0.0.3
+	golang.org/x/image v0.18.0
 )
 
 require (
@@ -26,7 +28,6 @@ require (
 	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/supabase-community/functions-go v0.0.0-20220927045802-22373e6cb51d // indirect
 	github.com/supabase-community/gotrue-go v1.2.0 // indirect
-	github.com/supabase-community/postgrest-go v0.0.11-0.20240521132850-9413d68fbc6d // indirect
 	github.com/supabase-community/storage-go v0.7.0 // indirect
 	github.com/tinylib/msgp v1.1.8 // indirect
 	github.com/tomnomnom/linkheader v0.0.0-20180905144013-02ca5825eb80 // indirect
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AccountHandler struct {
+	accountService *services.AccountService
+}
+
+func NewAccountHandler(accountService *services.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// ExportData downloads everything stored about the authenticated user.
+// ?format=zip adds their images to the JSON; the default is JSON only.
+func (h *AccountHandler) ExportData(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	filename := fmt.Sprintf("pesxchange-export-%s", time.Now().Format("2006-01-02"))
+	c.Set("Cache-Control", "private, no-store")
+
+	switch c.Query("format", "json") {
+	case "json":
+		export, err := h.accountService.ExportData(c.Context(), authenticatedUserID.(string))
+		if err != nil {
+			return accountError(c, err, "Failed to export data")
+		}
+
+		c.Attachment(filename + ".json")
+		return c.JSON(models.APIResponse{
+			Success: true,
+			Data:    export,
+		})
+	case "zip":
+		var buf bytes.Buffer
+		if err := h.accountService.WriteExportArchive(c.Context(), authenticatedUserID.(string), &buf); err != nil {
+			return accountError(c, err, "Failed to export data")
+		}
+
+		c.Attachment(filename + ".zip")
+		c.Set(fiber.HeaderContentType, "application/zip")
+		return c.Send(buf.Bytes())
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "format must be one of: json, zip",
+		})
+	}
+}
+
+// RequestDeletion schedules the authenticated user's account for deletion after the grace period
+func (h *AccountHandler) RequestDeletion(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	status, err := h.accountService.RequestDeletion(c.Context(), authenticatedUserID.(string))
+	if err != nil {
+		return accountError(c, err, "Failed to schedule account deletion")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(models.APIResponse{
+		Success: true,
+		Data:    status,
+		Message: fmt.Sprintf("Your account will be deleted on %s unless you cancel before then", status.ScheduledFor.Format("2 January 2006")),
+	})
+}
+
+// GetDeletionStatus reports whether the authenticated user's account is scheduled for deletion
+func (h *AccountHandler) GetDeletionStatus(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	status, err := h.accountService.GetDeletionStatus(c.Context(), authenticatedUserID.(string))
+	if err != nil {
+		return accountError(c, err, "Failed to retrieve deletion status")
+	}
+
+	c.Set("Cache-Control", "private, no-store")
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    status,
+	})
+}
+
+// CancelDeletion withdraws the authenticated user's pending deletion request
+func (h *AccountHandler) CancelDeletion(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	status, err := h.accountService.CancelDeletion(c.Context(), authenticatedUserID.(string))
+	if err != nil {
+		return accountError(c, err, "Failed to cancel account deletion")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    status,
+		Message: "Account deletion cancelled",
+	})
+}
+
+// accountError maps account errors to responses, falling back to a 500 with the given message
+func accountError(c *fiber.Ctx, err error, fallback string) error {
+	status := fiber.StatusInternalServerError
+	message := fallback
+
+	switch {
+	case err.Error() == "user not found":
+		status, message = fiber.StatusNotFound, "User not found"
+	case err.Error() == "deletion not scheduled":
+		status, message = fiber.StatusConflict, "Your account is not scheduled for deletion"
+	}
+
+	return c.Status(status).JSON(models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"pesxchange-backend/config"
+	"pesxchange-backend/middleware"
+	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminHandler struct {
+	uploadService          *services.UploadService
+	imageMigrationService  *services.ImageMigrationService
+	imageModerationService *services.ImageModerationService
+	userService            *services.UserService
+	cfg                    *config.Config
+	validator              *validator.Validate
+}
+
+func NewAdminHandler(uploadService *services.UploadService, imageMigrationService *services.ImageMigrationService, imageModerationService *services.ImageModerationService, userService *services.UserService, cfg *config.Config) *AdminHandler {
+	return &AdminHandler{
+		uploadService:          uploadService,
+		imageMigrationService:  imageMigrationService,
+		imageModerationService: imageModerationService,
+		userService:            userService,
+		cfg:                    cfg,
+		validator:              validator.New(),
+	}
+}
+
+// GetOrphanedUploads reports the uploads garbage collection would delete, without deleting anything
+func (h *AdminHandler) GetOrphanedUploads(c *fiber.Ctx) error {
+	return h.collectUploads(c, true)
+}
+
+// CollectOrphanedUploads runs upload garbage collection immediately
+func (h *AdminHandler) CollectOrphanedUploads(c *fiber.Ctx) error {
+	return h.collectUploads(c, false)
+}
+
+func (h *AdminHandler) collectUploads(c *fiber.Ctx, dryRun bool) error {
+	// Grace period defaults to UPLOAD_GC_GRACE_HOURS and can be overridden per request
+	graceHours := h.cfg.UploadGCGraceHours
+	if v := c.Query("grace_hours"); v != "" {
+		hours, err := strconv.Atoi(v)
+		if err != nil || hours < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "grace_hours must be a non-negative integer",
+			})
+		}
+		graceHours = hours
+	}
+
+	report, err := h.uploadService.CollectGarbage(c.Context(), time.Duration(graceHours)*time.Hour, dryRun)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to collect orphaned uploads",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// StartImageMigration starts or resumes the legacy base64 image migration in the background
+func (h *AdminHandler) StartImageMigration(c *fiber.Ctx) error {
+	migration, err := h.imageMigrationService.Start(c.Context())
+	if err != nil {
+		if err.Error() == "migration already running" {
+			return c.Status(fiber.StatusConflict).JSON(models.APIResponse{
+				Success: false,
+				Error:   "An image migration is already running",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to start image migration",
+		})
+	}
+
+	// The request context ends with the response, so the run gets its own
+	go func() {
+		if err := h.imageMigrationService.Run(context.Background(), migration, nil); err != nil {
+			log.Printf("Image migration %s failed: %v", migration.ID, err)
+		}
+	}()
+
+	return c.Status(fiber.StatusAccepted).JSON(models.APIResponse{
+		Success: true,
+		Data:    migration,
+		Message: "Image migration started",
+	})
+}
+
+// GetImageMigration returns the progress and per-item failures of the latest image migration
+func (h *AdminHandler) GetImageMigration(c *fiber.Ctx) error {
+	status, err := h.imageMigrationService.GetStatus(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to get image migration",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    status,
+	})
+}
+
+// GetImageFlags lists the image moderation queue (?status=open|dismissed|banned, default open)
+func (h *AdminHandler) GetImageFlags(c *fiber.Ctx) error {
+	status := c.Query("status", services.ImageFlagOpen)
+	if status != services.ImageFlagOpen && status != services.ImageFlagDismissed && status != services.ImageFlagBanned {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "status must be one of: open, dismissed, banned",
+		})
+	}
+
+	limit, offset := middleware.ParsePagination(c)
+
+	flags, err := h.imageModerationService.GetFlags(c.Context(), status, limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to get image flags",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    flags,
+	})
+}
+
+// ReviewImageFlag dismisses a flagged image or bans it so exact re-uploads are rejected
+func (h *AdminHandler) ReviewImageFlag(c *fiber.Ctx) error {
+	flagID := c.Params("id")
+	if flagID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Flag ID is required",
+		})
+	}
+
+	reviewerID, _ := c.Locals("userID").(string)
+
+	var req models.ReviewImageFlagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "status must be one of: dismissed, banned",
+		})
+	}
+
+	flag, err := h.imageModerationService.ReviewFlag(c.Context(), flagID, reviewerID, &req)
+	if err != nil {
+		if err.Error() == "flag not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Flag not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to review image flag",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    flag,
+		Message: "Image flag reviewed",
+	})
+}
+
+// SetTrustedSeller marks a user as a trusted seller, giving them the highest storage quota
+func (h *AdminHandler) SetTrustedSeller(c *fiber.Ctx) error {
+	userID := c.Params("id")
+	if userID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+	}
+
+	var req models.SetTrustedSellerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	if err := h.uploadService.SetTrustedSeller(c.Context(), userID, req.Trusted); err != nil {
+		if err.Error() == "user not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+				Success: false,
+				Error:   "User not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to update user",
+		})
+	}
+
+	usage, err := h.uploadService.GetStorageUsage(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to get storage usage",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    usage,
+		Message: "Trusted seller status updated",
+	})
+}
+
+// GetProfileHistory lists changes to a user's PESU profile data, such as SRN changes and
+// graduating to alumni, newest first
+func (h *AdminHandler) GetProfileHistory(c *fiber.Ctx) error {
+	limit, offset := middleware.ParsePagination(c)
+
+	entries, total, err := h.userService.GetProfileHistory(c.Context(), c.Params("id"), limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to get profile history",
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Data:    entries,
+		Pagination: models.Pagination{
+			Limit:  limit,
+			Offset: offset,
+			Total:  total,
+		},
+	})
+}
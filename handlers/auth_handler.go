@@ -54,6 +54,9 @@ func (h *AuthHandler) LoginWithPESU(c *fiber.Ctx) error {
 		   strings.Contains(err.Error(), "invalid SRN format") {
 			status = fiber.StatusUnauthorized
 			errorMsg = err.Error()
+		} else if strings.Contains(err.Error(), "account deleted") {
+			status = fiber.StatusForbidden
+			errorMsg = "This account has been deleted"
 		} else if strings.Contains(err.Error(), "authentication service unavailable") {
 			status = fiber.StatusServiceUnavailable
 			errorMsg = "Authentication service unavailable"
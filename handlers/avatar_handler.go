@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+	"pesxchange-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UploadAvatar sets the authenticated user's avatar from a multipart "avatar" image
+func (h *UserHandler) UploadAvatar(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "No avatar image provided",
+		})
+	}
+
+	// SECURITY: Validate file size
+	if file.Size > maxFileSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Avatar exceeds %s limit", formatMegabytes(maxFileSize)),
+		})
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to open avatar image",
+		})
+	}
+	defer src.Close()
+
+	// SECURITY: Validate file type using magic bytes
+	if _, _, err := validateImageFile(src); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Only JPEG, PNG and WebP images are allowed",
+		})
+	}
+
+	// Use LimitReader to prevent memory exhaustion
+	buf := new(bytes.Buffer)
+	written, err := buf.ReadFrom(io.LimitReader(src, maxFileSize+1))
+	if err != nil || written > maxFileSize {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to read avatar image",
+		})
+	}
+
+	user, err := h.userService.SetAvatar(c.UserContext(), authenticatedUserID.(string), buf.Bytes())
+	if err != nil {
+		if err.Error() == "image is banned" || strings.HasPrefix(err.Error(), "processing failed") {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "This image can't be used as an avatar",
+			})
+		}
+		if err.Error() == "user not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+				Success: false,
+				Error:   "User not found",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to update avatar",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data: fiber.Map{
+			"profile": user,
+			"avatar":  avatarVariants(user.AvatarURL),
+		},
+		Message: "Avatar updated successfully",
+	})
+}
+
+// DeleteAvatar removes the authenticated user's avatar, reverting to the initials avatar
+func (h *UserHandler) DeleteAvatar(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	user, err := h.userService.RemoveAvatar(c.UserContext(), authenticatedUserID.(string))
+	if err != nil {
+		if err.Error() == "user not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+				Success: false,
+				Error:   "User not found",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to remove avatar",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    user,
+		Message: "Avatar removed successfully",
+	})
+}
+
+// GetAvatar redirects to a user's avatar in the requested size ("small" or "large", the
+// default), or serves a generated initials avatar if they haven't set one
+func (h *UserHandler) GetAvatar(c *fiber.Ctx) error {
+	size := c.Query("size", utils.AvatarLarge)
+	if !utils.IsAvatarVariant(size) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Size must be one of: small, large",
+		})
+	}
+
+	user, err := h.userService.GetUserByID(c.Context(), c.Params("id"))
+	if err != nil {
+		return profileError(c, err)
+	}
+
+	// Short cache so a changed avatar shows up soon
+	c.Set("Cache-Control", "public, max-age=300")
+
+	if user.AvatarURL != "" {
+		return c.Redirect(services.AvatarVariantURL(user.AvatarURL, size), fiber.StatusFound)
+	}
+
+	pixels := 512
+	for _, v := range utils.AvatarVariants {
+		if v.Name == size {
+			pixels = v.MaxDimension
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, "image/svg+xml")
+	return c.Send(utils.InitialsAvatarSVG(user.Nickname, user.ID, pixels))
+}
+
+// avatarVariants lists an avatar's URL in every size
+func avatarVariants(avatarURL string) map[string]string {
+	urls := make(map[string]string, len(utils.AvatarVariants))
+	for _, v := range utils.AvatarVariants {
+		urls[v.Name] = services.AvatarVariantURL(avatarURL, v.Name)
+	}
+	return urls
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type BlockHandler struct {
+	blockService *services.BlockService
+}
+
+func NewBlockHandler(blockService *services.BlockService) *BlockHandler {
+	return &BlockHandler{
+		blockService: blockService,
+	}
+}
+
+// BlockUser handles blocking another user
+func (h *BlockHandler) BlockUser(c *fiber.Ctx) error {
+	blockedID := c.Params("id")
+	if blockedID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+	}
+
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	userID := authenticatedUserID.(string)
+
+	block, err := h.blockService.BlockUser(c.Context(), userID, blockedID)
+	if err != nil {
+		if err.Error() == "cannot block yourself" {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "You cannot block yourself",
+			})
+		}
+		if err.Error() == "user not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+				Success: false,
+				Error:   "User not found",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to block user",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Success: true,
+		Data:    block,
+		Message: "User blocked successfully",
+	})
+}
+
+// UnblockUser handles removing a block
+func (h *BlockHandler) UnblockUser(c *fiber.Ctx) error {
+	blockedID := c.Params("id")
+	if blockedID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+	}
+
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	userID := authenticatedUserID.(string)
+
+	if err := h.blockService.UnblockUser(c.Context(), userID, blockedID); err != nil {
+		if err.Error() == "block not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+				Success: false,
+				Error:   "User is not blocked",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to unblock user",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "User unblocked successfully",
+	})
+}
+
+// GetBlockedUsers lists the users the authenticated user has blocked
+func (h *BlockHandler) GetBlockedUsers(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	userID := authenticatedUserID.(string)
+
+	blocks, err := h.blockService.GetBlockedUsers(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to get blocked users",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    blocks,
+	})
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"pesxchange-backend/middleware"
+	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FollowHandler struct {
+	followService *services.FollowService
+	itemService   *services.ItemService
+}
+
+func NewFollowHandler(followService *services.FollowService, itemService *services.ItemService) *FollowHandler {
+	return &FollowHandler{
+		followService: followService,
+		itemService:   itemService,
+	}
+}
+
+// FollowUser makes the authenticated user follow a seller
+func (h *FollowHandler) FollowUser(c *fiber.Ctx) error {
+	followeeID := c.Params("id")
+	if followeeID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+	}
+
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	follow, err := h.followService.Follow(c.Context(), authenticatedUserID.(string), followeeID)
+	if err != nil {
+		switch err.Error() {
+		case "cannot follow yourself":
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "You cannot follow yourself",
+			})
+		case "user not found":
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+				Success: false,
+				Error:   "User not found",
+			})
+		case "user blocked":
+			return c.Status(fiber.StatusForbidden).JSON(models.APIResponse{
+				Success: false,
+				Error:   "You cannot follow this user",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to follow user",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Success: true,
+		Data:    follow,
+		Message: "User followed successfully",
+	})
+}
+
+// UnfollowUser stops the authenticated user following a seller
+func (h *FollowHandler) UnfollowUser(c *fiber.Ctx) error {
+	followeeID := c.Params("id")
+	if followeeID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "User ID is required",
+		})
+	}
+
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	if err := h.followService.Unfollow(c.Context(), authenticatedUserID.(string), followeeID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to unfollow user",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "User unfollowed successfully",
+	})
+}
+
+// GetFollowStatus reports whether the authenticated user follows a seller
+func (h *FollowHandler) GetFollowStatus(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	following, err := h.followService.IsFollowing(c.Context(), authenticatedUserID.(string), c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to check follow",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    fiber.Map{"following": following},
+	})
+}
+
+// GetFollowingFeed lists new items from the sellers the authenticated user follows
+func (h *FollowHandler) GetFollowingFeed(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	limit, offset := middleware.ParsePagination(c)
+
+	items, total, err := h.itemService.GetFollowingFeed(c.Context(), authenticatedUserID.(string), limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve feed",
+		})
+	}
+
+	c.Set("Cache-Control", "private, max-age=60")
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Data:    items,
+		Pagination: models.Pagination{
+			Limit:  limit,
+			Offset: offset,
+			Total:  total,
+		},
+	})
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"pesxchange-backend/config"
+	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HandleHandler struct {
+	userService *services.UserService
+	cfg         *config.Config
+}
+
+func NewHandleHandler(userService *services.UserService) *HandleHandler {
+	return &HandleHandler{
+		userService: userService,
+		cfg:         config.Load(),
+	}
+}
+
+// CheckHandle reports whether a handle is available. Signed-in users see their own handles as available.
+func (h *HandleHandler) CheckHandle(c *fiber.Ctx) error {
+	viewerID, _ := c.Locals("userID").(string)
+
+	availability, err := h.userService.CheckHandle(c.Context(), c.Params("handle"), viewerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to check handle",
+		})
+	}
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    availability,
+	})
+}
+
+// GetProfileByHandle gets a user profile by handle. Handles the user recently changed away from
+// redirect to the current one.
+func (h *HandleHandler) GetProfileByHandle(c *fiber.Ctx) error {
+	handle := c.Params("handle")
+
+	user, currentHandle, err := h.userService.GetUserByHandle(c.Context(), handle)
+	if err != nil {
+		return profileError(c, err)
+	}
+
+	if currentHandle != "" {
+		// Not permanent: the old handle is released once the redirect expires
+		location := strings.Replace(c.OriginalURL(), "/handles/"+handle+"/", "/handles/"+currentHandle+"/", 1)
+		c.Set("Cache-Control", "public, max-age=300")
+		return c.Redirect(location, fiber.StatusFound)
+	}
+
+	// The response depends on who is asking
+	c.Vary(fiber.HeaderAuthorization)
+
+	viewerID, _ := c.Locals("userID").(string)
+	if viewerID == user.ID || h.cfg.IsAdmin(viewerID) {
+		c.Set("Cache-Control", "private, no-store")
+		return c.JSON(models.APIResponse{
+			Success: true,
+			Data:    user,
+		})
+	}
+
+	profile, err := h.userService.GetPublicProfile(c.Context(), user.ID)
+	if err != nil {
+		return profileError(c, err)
+	}
+
+	c.Set("Cache-Control", "public, max-age=300")
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    profile,
+	})
+}
+
+// SetHandle claims or changes the authenticated user's handle
+func (h *HandleHandler) SetHandle(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	var req models.SetHandleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	user, err := h.userService.SetHandle(c.Context(), authenticatedUserID.(string), req.Handle)
+	if err != nil {
+		var cooldownErr *services.HandleCooldownError
+		if errors.As(err, &cooldownErr) {
+			return c.Status(fiber.StatusTooManyRequests).JSON(models.APIResponse{
+				Success: false,
+				Error:   fmt.Sprintf("You can only change your handle once every %d days", h.cfg.HandleChangeCooldownDays),
+				Data: fiber.Map{
+					"reason":         "handle_change_cooldown",
+					"next_change_at": cooldownErr.NextChangeAt,
+				},
+			})
+		}
+
+		switch err.Error() {
+		case "invalid handle":
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error: fmt.Sprintf("Handles must be %d-%d characters of letters, numbers and underscores, start with a letter and not end with an underscore",
+					services.MinHandleLength, services.MaxHandleLength),
+			})
+		case "handle reserved":
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "This handle is reserved",
+			})
+		case "handle not allowed":
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "This handle is not allowed",
+			})
+		case "handle taken":
+			return c.Status(fiber.StatusConflict).JSON(models.APIResponse{
+				Success: false,
+				Error:   "This handle is already taken",
+			})
+		case "user not found":
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+				Success: false,
+				Error:   "User not found",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to update handle",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    user,
+		Message: "Handle updated successfully",
+	})
+}
@@ -2,39 +2,53 @@ package handlers
 
 import (
 	"bytes"
-	"encoding/base64"
 	"fmt"
 	"io"
-	"mime/multipart"
+	"log"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
-	"pesxchange-backend/database"
 	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+	"pesxchange-backend/storage"
+	"pesxchange-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
-	supabase "github.com/supabase-community/supabase-go"
 )
 
 const (
-	maxFileSize         = 5 * 1024 * 1024  // 5MB per image
-	maxBase64Size       = 7000000           // ~5MB base64 encoded
-	maxImagesPerUpload  = 5                 // Maximum images per request
-	maxImageDimension   = 8192              // Maximum width/height in pixels
-	bucketName          = "item-images"     // Storage bucket name
+	maxFileSize         = services.MaxProxiedImageSize // Per image; multipart uploads must fit the request body limit
+	maxImagesPerUpload  = 5                            // Maximum images per request
+	bucketName          = services.ItemImagesBucket    // Storage bucket name
 )
 
-type ImageHandler struct{}
+type ImageHandler struct {
+	store         storage.ObjectStore
+	uploadService *services.UploadService
+}
 
 func NewImageHandler() *ImageHandler {
-	return &ImageHandler{}
+	return &ImageHandler{
+		store:         storage.GetStore(bucketName),
+		uploadService: services.NewUploadService(),
+	}
 }
 
-// UploadImage handles image upload to Supabase Storage with comprehensive security validations
+// UploadImage handles image upload to object storage with comprehensive security validations
 func (h *ImageHandler) UploadImage(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	userID := authenticatedUserID.(string)
+
 	// Parse multipart form
 	form, err := c.MultipartForm()
 	if err != nil {
@@ -60,15 +74,23 @@ func (h *ImageHandler) UploadImage(c *fiber.Ctx) error {
 		})
 	}
 
+	// Reject the whole request up front if it can't fit in the user's storage quota
+	var totalSize int64
+	for _, file := range files {
+		totalSize += file.Size
+	}
+	if err := h.uploadService.CheckQuota(c.Context(), userID, totalSize, len(files)); err != nil {
+		return quotaCheckResponse(c, err)
+	}
+
 	var uploadedURLs []string
+	var uploadedVariants []map[string]string
 	var rejectedFiles []string
-	// Use storage client (with service key) for uploads
-	storageClient := database.GetStorageClient()
 
 	for _, file := range files {
 		// SECURITY: Validate file size
 		if file.Size > maxFileSize {
-			rejectedFiles = append(rejectedFiles, fmt.Sprintf("%s (exceeds 5MB limit)", file.Filename))
+			rejectedFiles = append(rejectedFiles, fmt.Sprintf("%s (exceeds %s limit)", file.Filename, formatMegabytes(maxFileSize)))
 			continue
 		}
 
@@ -80,7 +102,7 @@ func (h *ImageHandler) UploadImage(c *fiber.Ctx) error {
 		}
 
 		// SECURITY: Validate file type using magic bytes
-		contentType, ext, err := validateImageFile(src)
+		_, _, err = validateImageFile(src)
 		if err != nil {
 			src.Close()
 			rejectedFiles = append(rejectedFiles, fmt.Sprintf("%s (invalid image type: %s)", file.Filename, err.Error()))
@@ -105,23 +127,25 @@ func (h *ImageHandler) UploadImage(c *fiber.Ctx) error {
 			continue
 		}
 
-		// Generate unique filename with validated extension
-		filename := fmt.Sprintf("%s_%d%s", 
+		// Generate unique base name; variants are stored as <base>_<variant>.jpg
+		baseName := fmt.Sprintf("%s_%d", 
 			uuid.New().String(), 
-			time.Now().Unix(), 
-			ext)
+			time.Now().Unix())
 
-		// Upload to Supabase Storage with proper content-type
-		err = uploadToSupabase(storageClient, bucketName, filename, buf.Bytes(), contentType)
-		
+		// Decode, check dimensions, resize and re-encode, then upload every variant
+		stored, err := services.StoreProcessedImage(c.UserContext(), h.store, baseName, buf.Bytes())
 		if err != nil {
-			rejectedFiles = append(rejectedFiles, fmt.Sprintf("%s (upload failed: %s)", file.Filename, err.Error()))
+			rejectedFiles = append(rejectedFiles, fmt.Sprintf("%s (%s)", file.Filename, err.Error()))
 			continue
 		}
 
-		// Get public URL
-		publicURL := storageClient.Storage.GetPublicUrl(bucketName, filename)
-		uploadedURLs = append(uploadedURLs, publicURL.SignedURL)
+		// Track the upload so it can be garbage collected if never attached to an item
+		if err := h.uploadService.RecordUpload(c.Context(), userID, stored, written); err != nil {
+			log.Printf("Failed to track upload: %v", err)
+		}
+
+		uploadedURLs = append(uploadedURLs, stored.URLs[utils.VariantFull])
+		uploadedVariants = append(uploadedVariants, stored.URLs)
 	}
 
 	// Return appropriate response
@@ -140,7 +164,8 @@ func (h *ImageHandler) UploadImage(c *fiber.Ctx) error {
 	response := models.APIResponse{
 		Success: true,
 		Data: map[string]interface{}{
-			"urls": uploadedURLs,
+			"urls":     uploadedURLs,
+			"variants": uploadedVariants,
 		},
 	}
 
@@ -152,8 +177,19 @@ func (h *ImageHandler) UploadImage(c *fiber.Ctx) error {
 	return c.JSON(response)
 }
 
-// ConvertBase64ToStorage converts existing base64 images to Supabase Storage with security validations
+// ConvertBase64ToStorage converts existing base64 images to object storage with security validations
 func (h *ImageHandler) ConvertBase64ToStorage(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	userID := authenticatedUserID.(string)
+
 	var req struct {
 		Images []string `json:"images"`
 		ItemID string   `json:"item_id"`
@@ -174,10 +210,23 @@ func (h *ImageHandler) ConvertBase64ToStorage(c *fiber.Ctx) error {
 		})
 	}
 
+	// Decoded base64 is about 3/4 of its encoded length
+	var totalSize int64
+	inlineImages := 0
+	for _, img := range req.Images {
+		if strings.HasPrefix(img, "data:image/") {
+			totalSize += int64(len(img)) * 3 / 4
+			inlineImages++
+		}
+	}
+	if inlineImages > 0 {
+		if err := h.uploadService.CheckQuota(c.Context(), userID, totalSize, inlineImages); err != nil {
+			return quotaCheckResponse(c, err)
+		}
+	}
+
 	var convertedURLs []string
 	var rejectedImages []string
-	// Use storage client (with service key) for uploads
-	storageClient := database.GetStorageClient()
 
 	for i, img := range req.Images {
 		if !strings.HasPrefix(img, "data:image/") {
@@ -186,54 +235,17 @@ func (h *ImageHandler) ConvertBase64ToStorage(c *fiber.Ctx) error {
 			continue
 		}
 
-		// Parse base64 image
-		parts := strings.Split(img, ",")
-		if len(parts) != 2 {
-			rejectedImages = append(rejectedImages, fmt.Sprintf("image %d (invalid format)", i))
-			continue
-		}
-
-		// SECURITY: Check base64 string size before decoding
-		if len(parts[1]) > maxBase64Size {
-			rejectedImages = append(rejectedImages, fmt.Sprintf("image %d (exceeds size limit)", i))
-			continue
-		}
+		// Generate base name; variants are stored as <base>_<variant>.jpg
+		baseName := fmt.Sprintf("%s_%d_%d", req.ItemID, time.Now().Unix(), i)
 
-		// Decode base64
-		imageData, err := base64.StdEncoding.DecodeString(parts[1])
+		// Validates size and magic bytes, stores the variants and tracks the upload
+		variantURLs, err := h.uploadService.ConvertBase64Image(c.UserContext(), userID, baseName, img)
 		if err != nil {
-			rejectedImages = append(rejectedImages, fmt.Sprintf("image %d (decode failed)", i))
+			rejectedImages = append(rejectedImages, fmt.Sprintf("image %d (%s)", i, err.Error()))
 			continue
 		}
 
-		// SECURITY: Validate decoded size
-		if len(imageData) > maxFileSize {
-			rejectedImages = append(rejectedImages, fmt.Sprintf("image %d (decoded size exceeds 5MB)", i))
-			continue
-		}
-
-		// SECURITY: Validate image using magic bytes
-		contentType := http.DetectContentType(imageData)
-		ext, err := getExtensionFromContentType(contentType)
-		if err != nil {
-			rejectedImages = append(rejectedImages, fmt.Sprintf("image %d (invalid image type)", i))
-			continue
-		}
-
-		// Generate filename with validated extension
-		filename := fmt.Sprintf("%s_%d_%d%s", req.ItemID, time.Now().Unix(), i, ext)
-
-		// Upload to Supabase Storage with proper content-type
-		err = uploadToSupabase(storageClient, bucketName, filename, imageData, contentType)
-		
-		if err != nil {
-			rejectedImages = append(rejectedImages, fmt.Sprintf("image %d (upload failed: %s)", i, err.Error()))
-			continue
-		}
-
-		// Get public URL
-		publicURL := storageClient.Storage.GetPublicUrl(bucketName, filename)
-		convertedURLs = append(convertedURLs, publicURL.SignedURL)
+		convertedURLs = append(convertedURLs, variantURLs[utils.VariantFull])
 	}
 
 	// Return appropriate response
@@ -294,75 +306,10 @@ func validateImageFile(file io.ReadSeeker) (string, string, error) {
 
 // getExtensionFromContentType maps MIME types to file extensions
 func getExtensionFromContentType(contentType string) (string, error) {
-	validTypes := map[string]string{
-		"image/jpeg": ".jpg",
-		"image/png":  ".png",
-		"image/webp": ".webp",
-		// Note: GIF removed for security - can contain executable code
-	}
-
-	ext, ok := validTypes[contentType]
-	if !ok {
-		return "", fmt.Errorf("unsupported image type: %s", contentType)
-	}
-
-	return ext, nil
+	return services.ImageExtension(contentType)
 }
 
-// uploadToSupabase uploads a file to Supabase Storage with proper content-type
-// This bypasses the SDK's UploadFile which doesn't set content-type correctly
-func uploadToSupabase(client *supabase.Client, bucket, filename string, data []byte, contentType string) error {
-	// Create multipart form
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	// Create form file header with explicit content-type
-	h := make(map[string][]string)
-	h["Content-Disposition"] = []string{fmt.Sprintf(`form-data; name="file"; filename="%s"`, filename)}
-	h["Content-Type"] = []string{contentType}
-	
-	part, err := writer.CreatePart(h)
-	if err != nil {
-		return fmt.Errorf("failed to create form part: %w", err)
-	}
-
-	_, err = part.Write(data)
-	if err != nil {
-		return fmt.Errorf("failed to write file data: %w", err)
-	}
-
-	err = writer.Close()
-	if err != nil {
-		return fmt.Errorf("failed to close multipart writer: %w", err)
-	}
-
-	// Make HTTP request directly
-	supabaseURL := os.Getenv("SUPABASE_URL")
-	url := fmt.Sprintf("%s/storage/v1/object/%s/%s", supabaseURL, bucket, filename)
-
-	req, err := http.NewRequest("POST", url, body)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", os.Getenv("SUPABASE_SERVICE_KEY")))
-	req.Header.Set("apikey", os.Getenv("SUPABASE_SERVICE_KEY"))
-
-	// Execute request
-	httpClient := &http.Client{Timeout: 30 * time.Second}
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("upload request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	return nil
+// formatMegabytes formats a byte size for error messages, e.g. "1.9MB"
+func formatMegabytes(size int64) string {
+	return fmt.Sprintf("%.1fMB", float64(size)/(1024*1024))
 }
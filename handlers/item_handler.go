@@ -1,27 +1,34 @@
 package handlers
 
 import (
-	"encoding/base64"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 
 	"pesxchange-backend/middleware"
 	"pesxchange-backend/models"
 	"pesxchange-backend/services"
+	"pesxchange-backend/utils"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 )
 
 type ItemHandler struct {
-	itemService *services.ItemService
-	validator   *validator.Validate
+	itemService       *services.ItemService
+	imageProxyService *services.ImageProxyService
+	validator         *validator.Validate
 }
 
 func NewItemHandler(itemService *services.ItemService) *ItemHandler {
 	return &ItemHandler{
-		itemService: itemService,
-		validator:   validator.New(),
+		itemService:       itemService,
+		imageProxyService: services.NewImageProxyService(),
+		validator:         validator.New(),
 	}
 }
 
@@ -43,27 +50,9 @@ func (h *ItemHandler) CreateItem(c *fiber.Ctx) error {
 	
 	// Validate request
 	if err := h.validator.Struct(&req); err != nil {
-		// Create more user-friendly error messages
-		var errorMsgs []string
-		for _, err := range err.(validator.ValidationErrors) {
-			switch err.Tag() {
-			case "required":
-				errorMsgs = append(errorMsgs, err.Field()+" is required")
-			case "min":
-				errorMsgs = append(errorMsgs, err.Field()+" must be at least "+err.Param()+" characters long")
-			case "max":
-				errorMsgs = append(errorMsgs, err.Field()+" must be less than "+err.Param()+" characters")
-			case "gt":
-				errorMsgs = append(errorMsgs, err.Field()+" must be greater than "+err.Param())
-			case "oneof":
-				errorMsgs = append(errorMsgs, err.Field()+" must be one of: "+err.Param())
-			default:
-				errorMsgs = append(errorMsgs, err.Field()+" is invalid")
-			}
-		}
 		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
 			Success: false,
-			Error:   strings.Join(errorMsgs, ", "),
+			Error:   validationErrorMessage(err),
 		})
 	}
 	
@@ -90,6 +79,22 @@ func (h *ItemHandler) CreateItem(c *fiber.Ctx) error {
 	
 	item, err := h.itemService.CreateItem(c.Context(), &req)
 	if err != nil {
+		var limitErr *services.AlumniListingLimitError
+		if errors.As(err, &limitErr) {
+			return alumniListingLimitResponse(c, limitErr)
+		}
+		if err.Error() == "unverified image upload" {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Complete image uploads before attaching them to an item",
+			})
+		}
+		if err.Error() == "invalid image" {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Images must be uploaded images or inline JPEG, PNG or WebP data",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
 			Success: false,
 			Error:   "Failed to create item",
@@ -149,6 +154,12 @@ func (h *ItemHandler) GetItems(c *fiber.Ctx) error {
 		}
 	}
 	
+	// Signed-in viewers get a feed without their blocked users' listings
+	viewerID, _ := c.Locals("userID").(string)
+	if viewerID != "" {
+		filters["viewer_id"] = viewerID
+	}
+	
 	items, total, err := h.itemService.GetItems(c.Context(), limit, offset, filters)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
@@ -158,7 +169,11 @@ func (h *ItemHandler) GetItems(c *fiber.Ctx) error {
 	}
 	
 	// Set cache headers for item listings (1 minute to keep data fresh)
-	c.Set("Cache-Control", "public, max-age=60")
+	if viewerID != "" {
+		c.Set("Cache-Control", "private, max-age=60")
+	} else {
+		c.Set("Cache-Control", "public, max-age=60")
+	}
 	c.Set("Connection", "keep-alive")
 	
 	return c.JSON(models.PaginatedResponse{
@@ -233,15 +248,22 @@ func (h *ItemHandler) UpdateItem(c *fiber.Ctx) error {
 	
 	sellerID := authenticatedUserID.(string)
 	
-	var updates map[string]interface{}
-	if err := c.BodyParser(&updates); err != nil {
+	var req models.UpdateItemRequest
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
 			Success: false,
 			Error:   "Invalid request body",
 		})
 	}
 	
-	item, err := h.itemService.UpdateItem(c.Context(), itemID, sellerID, updates)
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   validationErrorMessage(err),
+		})
+	}
+	
+	item, err := h.itemService.UpdateItem(c.Context(), itemID, sellerID, &req)
 	if err != nil {
 		if err.Error() == "item not found" {
 			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
@@ -255,6 +277,28 @@ func (h *ItemHandler) UpdateItem(c *fiber.Ctx) error {
 				Error:   "You can only edit your own items",
 			})
 		}
+		var limitErr *services.AlumniListingLimitError
+		if errors.As(err, &limitErr) {
+			return alumniListingLimitResponse(c, limitErr)
+		}
+		if err.Error() == "unverified image upload" {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Complete image uploads before attaching them to an item",
+			})
+		}
+		if err.Error() == "item already sold" {
+			return c.Status(fiber.StatusConflict).JSON(models.APIResponse{
+				Success: false,
+				Error:   "This item has been sold; cancel the sale to list it again",
+			})
+		}
+		if err.Error() == "invalid image" {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Images must be uploaded images or inline JPEG, PNG or WebP data",
+			})
+		}
 		
 		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
 			Success: false,
@@ -317,7 +361,8 @@ func (h *ItemHandler) DeleteItem(c *fiber.Ctx) error {
 	})
 }
 
-// GetItemImage serves individual item images
+// GetItemImage serves an item image from storage or legacy inline data. ?size=thumb|medium|full
+// selects a variant. Conditional (ETag/Last-Modified) and Range requests are supported.
 func (h *ItemHandler) GetItemImage(c *fiber.Ctx) error {
 	itemID := c.Params("id")
 	imageIndex := c.Params("index", "0")
@@ -330,66 +375,47 @@ func (h *ItemHandler) GetItemImage(c *fiber.Ctx) error {
 		})
 	}
 	
-	// Get the item from database
-	item, err := h.itemService.GetItemByID(c.Context(), itemID)
-	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
-			Success: false,
-			Error:   "Item not found",
-		})
-	}
-	
-	// Check if image index exists
-	if idx >= len(item.Images) || idx < 0 {
-		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+	size := c.Query("size")
+	if size != "" && !utils.IsVariant(size) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
 			Success: false,
-			Error:   "Image not found",
+			Error:   "size must be one of: thumb, medium, full",
 		})
 	}
 	
-	imageData := item.Images[idx]
-	
-	// Check if it's base64 data
-	if strings.HasPrefix(imageData, "data:image/") {
-		// Parse base64 image
-		parts := strings.Split(imageData, ",")
-		if len(parts) != 2 {
-			return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+	content, err := h.imageProxyService.GetItemImage(c.UserContext(), itemID, idx, size)
+	if err != nil {
+		if err.Error() == "item not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
 				Success: false,
-				Error:   "Invalid image format",
+				Error:   "Item not found",
 			})
 		}
-		
-		// Extract content type
-		header := parts[0]
-		var contentType string
-		if strings.Contains(header, "image/jpeg") || strings.Contains(header, "image/jpg") {
-			contentType = "image/jpeg"
-		} else if strings.Contains(header, "image/png") {
-			contentType = "image/png"
-		} else if strings.Contains(header, "image/webp") {
-			contentType = "image/webp"
-		} else {
-			contentType = "image/jpeg" // default
-		}
-		
-		// Decode base64 data
-		data, err := base64.StdEncoding.DecodeString(parts[1])
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+		if err.Error() == "image not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
 				Success: false,
-				Error:   "Failed to decode image data",
+				Error:   "Image not found",
 			})
 		}
 		
-		// Set proper headers and serve the image
-		c.Set("Content-Type", contentType)
-		c.Set("Cache-Control", "public, max-age=86400") // Cache for 24 hours
-		return c.Send(data)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to load image",
+		})
 	}
 	
-	// If it's already a URL, redirect to it
-	return c.Redirect(imageData)
+	// Images hosted elsewhere can't be proxied
+	if content.RedirectURL != "" {
+		return c.Redirect(content.RedirectURL)
+	}
+	
+	// http.ServeContent handles If-None-Match, If-Modified-Since, If-Range and Range
+	return adaptor.HTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", content.ContentType)
+		w.Header().Set("ETag", content.ETag)
+		w.Header().Set("Cache-Control", "public, max-age=3600") // Reordering changes what an index serves
+		http.ServeContent(w, r, "", content.LastModified, bytes.NewReader(content.Data))
+	})(c)
 }
 
 // GetItemsBySeller handles getting items by seller ID
@@ -430,4 +456,22 @@ func (h *ItemHandler) GetItemsBySeller(c *fiber.Ctx) error {
 		Data:    items,
 		Message: "Seller items retrieved successfully",
 	})
-}
\ No newline at end of file
+}
+
+// alumniListingLimitResponse reports an item that can't be listed because its graduated
+// seller is at the alumni listing cap
+func alumniListingLimitResponse(c *fiber.Ctx, err *services.AlumniListingLimitError) error {
+	errorMsg := "Alumni accounts can't list items. You can still mark your existing items as sold."
+	if err.Limit > 0 {
+		errorMsg = fmt.Sprintf("Alumni accounts can have at most %d items listed at once. Mark an item as sold or unavailable first.", err.Limit)
+	}
+	
+	return c.Status(fiber.StatusForbidden).JSON(models.APIResponse{
+		Success: false,
+		Error:   errorMsg,
+		Data: fiber.Map{
+			"reason": "alumni_listing_limit",
+			"limit":  err.Limit,
+		},
+	})
+}
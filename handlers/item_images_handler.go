@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"strings"
+
+	"pesxchange-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReorderItemImages sets the order of an item's images; the first image is the cover
+func (h *ItemHandler) ReorderItemImages(c *fiber.Ctx) error {
+	itemID := c.Params("id")
+
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	sellerID := authenticatedUserID.(string)
+
+	var req models.ReorderItemImagesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "image_ids is required",
+		})
+	}
+
+	images, err := h.itemService.ReorderImages(c.Context(), itemID, sellerID, req.ImageIDs)
+	if err != nil {
+		return itemImageError(c, err, "Failed to reorder images")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    images,
+		Message: "Images reordered successfully",
+	})
+}
+
+// SetItemCoverImage moves an image to the front of an item's images
+func (h *ItemHandler) SetItemCoverImage(c *fiber.Ctx) error {
+	itemID := c.Params("id")
+	imageID := c.Params("imageId")
+
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	sellerID := authenticatedUserID.(string)
+
+	images, err := h.itemService.SetCoverImage(c.Context(), itemID, sellerID, imageID)
+	if err != nil {
+		return itemImageError(c, err, "Failed to set cover image")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    images,
+		Message: "Cover image updated successfully",
+	})
+}
+
+// UpdateItemImage replaces an image's file and/or caption
+func (h *ItemHandler) UpdateItemImage(c *fiber.Ctx) error {
+	itemID := c.Params("id")
+	imageID := c.Params("imageId")
+
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	sellerID := authenticatedUserID.(string)
+
+	var req models.UpdateItemImageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "url must be a valid URL and caption at most 200 characters",
+		})
+	}
+
+	if req.URL == nil && req.Caption == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Provide a url or caption to update",
+		})
+	}
+
+	images, err := h.itemService.UpdateImage(c.Context(), itemID, sellerID, imageID, &req)
+	if err != nil {
+		return itemImageError(c, err, "Failed to update image")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    images,
+		Message: "Image updated successfully",
+	})
+}
+
+// RemoveItemImage removes an image from an item
+func (h *ItemHandler) RemoveItemImage(c *fiber.Ctx) error {
+	itemID := c.Params("id")
+	imageID := c.Params("imageId")
+
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	sellerID := authenticatedUserID.(string)
+
+	images, err := h.itemService.RemoveImage(c.Context(), itemID, sellerID, imageID)
+	if err != nil {
+		return itemImageError(c, err, "Failed to remove image")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    images,
+		Message: "Image removed successfully",
+	})
+}
+
+// itemImageError maps errors from the item image endpoints to responses
+func itemImageError(c *fiber.Ctx, err error, fallback string) error {
+	status := fiber.StatusInternalServerError
+	message := fallback
+
+	switch {
+	case err.Error() == "item not found":
+		status, message = fiber.StatusNotFound, "Item not found"
+	case err.Error() == "image not found":
+		status, message = fiber.StatusNotFound, "Image not found"
+	case strings.Contains(err.Error(), "unauthorized"):
+		status, message = fiber.StatusForbidden, "You can only edit your own items"
+	case err.Error() == "item has inline images":
+		status, message = fiber.StatusConflict, "This item's images are still being migrated; try again later"
+	case err.Error() == "image IDs must match the item's images":
+		status, message = fiber.StatusBadRequest, "image_ids must list each of the item's images exactly once"
+	case err.Error() == "inline images are not supported":
+		status, message = fiber.StatusBadRequest, "Upload the image first and use its URL"
+	case err.Error() == "unverified image upload":
+		status, message = fiber.StatusBadRequest, "Complete image uploads before attaching them to an item"
+	case err.Error() == "invalid image":
+		status, message = fiber.StatusBadRequest, "Image URL must be an uploaded image"
+	}
+
+	return c.Status(status).JSON(models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
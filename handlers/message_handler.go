@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"strconv"
+	"strings"
+
 	"pesxchange-backend/middleware"
 	"pesxchange-backend/models"
 	"pesxchange-backend/services"
@@ -61,6 +65,11 @@ func (h *MessageHandler) SendMessage(c *fiber.Ctx) error {
 	
 	message, err := h.messageService.SendMessage(c.Context(), userID, &req)
 	if err != nil {
+		var rateLimitErr *services.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			return rateLimitResponse(c, rateLimitErr)
+		}
+		
 		status := fiber.StatusInternalServerError
 		errorMsg := "Failed to send message"
 		
@@ -70,6 +79,12 @@ func (h *MessageHandler) SendMessage(c *fiber.Ctx) error {
 		} else if err.Error() == "item is not active" {
 			status = fiber.StatusBadRequest
 			errorMsg = err.Error()
+		} else if err.Error() == "user is blocked" {
+			status = fiber.StatusForbidden
+			errorMsg = "You cannot message this user"
+		} else if err.Error() == "message blocked by screening" {
+			status = fiber.StatusUnprocessableEntity
+			errorMsg = "This message looks like a scam or payment request and was not sent"
 		}
 		
 		return c.Status(status).JSON(models.APIResponse{
@@ -113,6 +128,13 @@ func (h *MessageHandler) GetMessages(c *fiber.Ctx) error {
 	
 	messages, err := h.messageService.GetMessages(c.Context(), userID, otherUserID, itemID, limit, offset)
 	if err != nil {
+		if err.Error() == "user is blocked" {
+			return c.Status(fiber.StatusForbidden).JSON(models.APIResponse{
+				Success: false,
+				Error:   "This conversation is unavailable",
+			})
+		}
+		
 		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
 			Success: false,
 			Error:   "Failed to get messages",
@@ -143,7 +165,10 @@ func (h *MessageHandler) GetActiveChats(c *fiber.Ctx) error {
 	
 	userID := authenticatedUserID.(string)
 	
-	chats, err := h.messageService.GetActiveChats(c.Context(), userID)
+	// Archived conversations are listed separately with ?archived=true
+	archived := c.Query("archived") == "true"
+	
+	chats, err := h.messageService.GetActiveChats(c.Context(), userID, archived)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
 			Success: false,
@@ -201,4 +226,295 @@ func (h *MessageHandler) MarkAsRead(c *fiber.Ctx) error {
 		Success: true,
 		Message: "Messages marked as read",
 	})
-}
\ No newline at end of file
+}
+
+// EditMessage handles editing a sent message within the edit window
+func (h *MessageHandler) EditMessage(c *fiber.Ctx) error {
+	messageID := c.Params("id")
+	if messageID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Message ID is required",
+		})
+	}
+	
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+	
+	userID := authenticatedUserID.(string)
+	
+	var req models.EditMessageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+	
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Validation failed: " + err.Error(),
+		})
+	}
+	
+	message, err := h.messageService.EditMessage(c.Context(), userID, messageID, &req)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		errorMsg := "Failed to edit message"
+		
+		if err.Error() == "message not found" {
+			status = fiber.StatusNotFound
+			errorMsg = "Message not found"
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = fiber.StatusForbidden
+			errorMsg = "You can only edit your own messages"
+		} else if err.Error() == "edit window has expired" || err.Error() == "message has been unsent" {
+			status = fiber.StatusBadRequest
+			errorMsg = err.Error()
+		} else if err.Error() == "message blocked by screening" {
+			status = fiber.StatusUnprocessableEntity
+			errorMsg = "This message looks like a scam or payment request and was not saved"
+		}
+		
+		return c.Status(status).JSON(models.APIResponse{
+			Success: false,
+			Error:   errorMsg,
+		})
+	}
+	
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    message,
+		Message: "Message edited successfully",
+	})
+}
+
+// GetMessageEdits handles retrieving the edit history of a message
+func (h *MessageHandler) GetMessageEdits(c *fiber.Ctx) error {
+	messageID := c.Params("id")
+	if messageID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Message ID is required",
+		})
+	}
+	
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+	
+	userID := authenticatedUserID.(string)
+	
+	edits, err := h.messageService.GetMessageEdits(c.Context(), userID, messageID)
+	if err != nil {
+		if err.Error() == "message not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Message not found",
+			})
+		}
+		
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to get message history",
+		})
+	}
+	
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    edits,
+	})
+}
+
+// DeleteMessage handles unsending a message (?for=everyone) or deleting it for the current user only
+func (h *MessageHandler) DeleteMessage(c *fiber.Ctx) error {
+	messageID := c.Params("id")
+	if messageID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Message ID is required",
+		})
+	}
+	
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+	
+	userID := authenticatedUserID.(string)
+	
+	scope := c.Query("for", "me")
+	if scope != "me" && scope != "everyone" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "for must be one of: me, everyone",
+		})
+	}
+	
+	err := h.messageService.DeleteMessage(c.Context(), userID, messageID, scope == "everyone")
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		errorMsg := "Failed to delete message"
+		
+		if err.Error() == "message not found" {
+			status = fiber.StatusNotFound
+			errorMsg = "Message not found"
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = fiber.StatusForbidden
+			errorMsg = "You can only unsend your own messages"
+		} else if err.Error() == "unsend window has expired" {
+			status = fiber.StatusBadRequest
+			errorMsg = err.Error()
+		}
+		
+		return c.Status(status).JSON(models.APIResponse{
+			Success: false,
+			Error:   errorMsg,
+		})
+	}
+	
+	message := "Message deleted"
+	if scope == "everyone" {
+		message = "Message unsent"
+	}
+	
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: message,
+	})
+}
+
+// UpdateConversation handles archiving and muting a conversation
+func (h *MessageHandler) UpdateConversation(c *fiber.Ctx) error {
+	otherUserID := c.Params("otherUserId")
+	if otherUserID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Other user ID is required",
+		})
+	}
+	
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+	
+	userID := authenticatedUserID.(string)
+	
+	var req models.UpdateConversationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+	
+	if req.Archived == nil && req.Muted == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "archived or muted is required",
+		})
+	}
+	
+	settings, err := h.messageService.UpdateConversationSettings(c.Context(), userID, otherUserID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to update conversation",
+		})
+	}
+	
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    settings,
+		Message: "Conversation updated successfully",
+	})
+}
+
+// rateLimitResponse reports an exhausted messaging quota with a Retry-After header
+func rateLimitResponse(c *fiber.Ctx, err *services.RateLimitError) error {
+	retryAfter := err.RetryAfterSeconds()
+	c.Set("Retry-After", strconv.Itoa(retryAfter))
+	
+	errorMsg := "You are sending messages too quickly. Please slow down."
+	switch err.Reason {
+	case services.RateLimitNewConversations:
+		errorMsg = "You have started too many new conversations today. Please try again later."
+	case services.RateLimitSlowMode:
+		errorMsg = "New accounts must wait a little between messages."
+	}
+	
+	return c.Status(fiber.StatusTooManyRequests).JSON(models.APIResponse{
+		Success: false,
+		Error:   errorMsg,
+		Data: fiber.Map{
+			"reason":      err.Reason,
+			"retry_after": retryAfter,
+		},
+	})
+}
+
+// SearchMessages handles searching the authenticated user's message history
+func (h *MessageHandler) SearchMessages(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+	
+	userID := authenticatedUserID.(string)
+	
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "q is required",
+		})
+	}
+	
+	// Number of messages to return around each hit for jump-to-context
+	contextSize, _ := strconv.Atoi(c.Query("context", "0"))
+	
+	groups, err := h.messageService.SearchMessages(c.Context(), userID, query, contextSize)
+	if err != nil {
+		if err.Error() == "search query too short" {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Search query must be at least 2 characters",
+			})
+		}
+		
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to search messages",
+		})
+	}
+	
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    groups,
+	})
+}
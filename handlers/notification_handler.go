@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"pesxchange-backend/middleware"
+	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+	"pesxchange-backend/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+	validator           *validator.Validate
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		validator:           utils.NewValidator(),
+	}
+}
+
+// GetNotifications lists the authenticated user's notifications; ?unread=true shows only unread ones
+func (h *NotificationHandler) GetNotifications(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	limit, offset := middleware.ParsePagination(c)
+
+	notifications, unread, err := h.notificationService.GetNotifications(c.Context(), authenticatedUserID.(string), c.QueryBool("unread"), limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve notifications",
+		})
+	}
+
+	c.Set("Cache-Control", "private, no-store")
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data: fiber.Map{
+			"notifications": notifications,
+			"unread_count":  unread,
+		},
+	})
+}
+
+// MarkNotificationsRead marks some or all of the authenticated user's notifications as read
+func (h *NotificationHandler) MarkNotificationsRead(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	var req models.MarkNotificationsReadRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "ids must be notification IDs",
+		})
+	}
+
+	if err := h.notificationService.MarkRead(c.Context(), authenticatedUserID.(string), req.IDs, req.All); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to mark notifications as read",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Notifications marked as read",
+	})
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"strings"
+
+	"pesxchange-backend/middleware"
+	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+	"pesxchange-backend/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReviewHandler struct {
+	reviewService *services.ReviewService
+	validator     *validator.Validate
+}
+
+func NewReviewHandler(reviewService *services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: reviewService,
+		validator:     utils.NewValidator(),
+	}
+}
+
+// CreateReview reviews the other party of a sold item
+func (h *ReviewHandler) CreateReview(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	var req models.CreateReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	req.Text = strings.TrimSpace(req.Text)
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   validationErrorMessage(err),
+		})
+	}
+
+	review, err := h.reviewService.CreateReview(c.Context(), authenticatedUserID.(string), &req)
+	if err != nil {
+		return reviewError(c, err, "Failed to create review")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Success: true,
+		Data:    review,
+		Message: "Review posted successfully",
+	})
+}
+
+// ReplyToReview posts the reviewed seller's public reply
+func (h *ReviewHandler) ReplyToReview(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	var req models.ReplyToReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	req.Reply = strings.TrimSpace(req.Reply)
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   validationErrorMessage(err),
+		})
+	}
+
+	review, err := h.reviewService.ReplyToReview(c.Context(), c.Params("id"), authenticatedUserID.(string), req.Reply)
+	if err != nil {
+		return reviewError(c, err, "Failed to reply to review")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    review,
+		Message: "Reply posted successfully",
+	})
+}
+
+// GetUserReviews lists the reviews a user has received
+func (h *ReviewHandler) GetUserReviews(c *fiber.Ctx) error {
+	limit, offset := middleware.ParsePagination(c)
+
+	reviews, total, err := h.reviewService.GetUserReviews(c.Context(), c.Params("id"), limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve reviews",
+		})
+	}
+
+	c.Set("Cache-Control", "public, max-age=300")
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Data:    reviews,
+		Pagination: models.Pagination{
+			Limit:  limit,
+			Offset: offset,
+			Total:  total,
+		},
+	})
+}
+
+// reviewError maps review errors to responses, falling back to a 500 with the given message
+func reviewError(c *fiber.Ctx, err error, fallback string) error {
+	status := fiber.StatusInternalServerError
+	message := fallback
+
+	switch {
+	case err.Error() == "item not found":
+		status, message = fiber.StatusNotFound, "Item not found"
+	case err.Error() == "review not found":
+		status, message = fiber.StatusNotFound, "Review not found"
+	case err.Error() == "item not sold":
+		status, message = fiber.StatusBadRequest, "Reviews can only be left once the buyer has confirmed the sale"
+	case err.Error() == "already reviewed":
+		status, message = fiber.StatusConflict, "You have already reviewed this sale"
+	case err.Error() == "already replied":
+		status, message = fiber.StatusConflict, "You have already replied to this review"
+	case strings.Contains(err.Error(), "not a party to the sale"):
+		status, message = fiber.StatusForbidden, "Only the buyer and seller can review a sale"
+	case strings.Contains(err.Error(), "unauthorized"):
+		status, message = fiber.StatusForbidden, "Only the reviewed seller can reply to a review"
+	}
+
+	return c.Status(status).JSON(models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
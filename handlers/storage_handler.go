@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"pesxchange-backend/models"
+	"pesxchange-backend/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StorageHandler struct{}
+
+func NewStorageHandler() *StorageHandler {
+	return &StorageHandler{}
+}
+
+// UploadLocalObject accepts a direct upload to the local storage backend. The signature
+// from SignedUploadURL binds the content type and exact size, so anything else is rejected.
+func (h *StorageHandler) UploadLocalObject(c *fiber.Ctx) error {
+	bucket := c.Params("bucket")
+	key := c.Params("*")
+	if !storage.IsValidBucketName(bucket) || key == "" {
+		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Object not found",
+		})
+	}
+
+	store, ok := storage.GetStore(bucket).(*storage.LocalStore)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Object not found",
+		})
+	}
+
+	contentType := c.Get(fiber.HeaderContentType)
+	size := c.Query("size")
+	if !store.VerifySignature(fiber.MethodPut, key, c.Query("expires"), c.Query("signature"), contentType, size) {
+		return c.Status(fiber.StatusForbidden).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid or expired signature",
+		})
+	}
+
+	body := c.Body()
+	if strconv.Itoa(len(body)) != size {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Upload size does not match the signed size",
+		})
+	}
+
+	if err := store.Put(c.UserContext(), key, body, contentType); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to store object",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Message: "Object uploaded successfully",
+	})
+}
+
+// ServeLocalObject serves files for the local storage backend. Requests carrying
+// expires/signature query parameters (from SignedURL) are rejected if the signature is invalid.
+func (h *StorageHandler) ServeLocalObject(c *fiber.Ctx) error {
+	bucket := c.Params("bucket")
+	key := c.Params("*")
+	if !storage.IsValidBucketName(bucket) || key == "" {
+		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Object not found",
+		})
+	}
+
+	store, ok := storage.GetStore(bucket).(*storage.LocalStore)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Object not found",
+		})
+	}
+
+	signature := c.Query("signature")
+	if signature != "" || c.Query("expires") != "" {
+		if !store.VerifySignature(fiber.MethodGet, key, c.Query("expires"), signature) {
+			return c.Status(fiber.StatusForbidden).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Invalid or expired signature",
+			})
+		}
+	}
+
+	obj, err := store.Get(c.UserContext(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Object not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to read object",
+		})
+	}
+
+	c.Set("Content-Type", obj.ContentType)
+	c.Set("ETag", obj.ETag)
+	c.Set("Last-Modified", obj.LastModified.UTC().Format(http.TimeFormat))
+	c.Set("Cache-Control", "public, max-age=86400") // Cache for 24 hours
+	return c.Send(obj.Data)
+}
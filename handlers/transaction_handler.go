@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"strings"
+
+	"pesxchange-backend/middleware"
+	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+	"pesxchange-backend/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TransactionHandler struct {
+	transactionService *services.TransactionService
+	validator          *validator.Validate
+}
+
+func NewTransactionHandler(transactionService *services.TransactionService) *TransactionHandler {
+	return &TransactionHandler{
+		transactionService: transactionService,
+		validator:          utils.NewValidator(),
+	}
+}
+
+// MarkItemSold starts a sale of one of the authenticated user's items to a conversation partner
+func (h *TransactionHandler) MarkItemSold(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	var req models.MarkItemSoldRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   validationErrorMessage(err),
+		})
+	}
+
+	transaction, err := h.transactionService.CreateTransaction(c.Context(), c.Params("id"), authenticatedUserID.(string), &req)
+	if err != nil {
+		return transactionError(c, err, "Failed to mark item as sold")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Success: true,
+		Data:    transaction,
+		Message: "Item marked as sold; waiting for the buyer to confirm",
+	})
+}
+
+// GetBuyerCandidates lists the users the authenticated seller can mark an item sold to
+func (h *TransactionHandler) GetBuyerCandidates(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	users, err := h.transactionService.GetBuyerCandidates(c.Context(), c.Params("id"), authenticatedUserID.(string))
+	if err != nil {
+		return transactionError(c, err, "Failed to retrieve buyers")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    users,
+	})
+}
+
+// GetMyTransactions lists the authenticated user's purchase and sales history.
+// ?role=buyer|seller and ?status= filter the list.
+func (h *TransactionHandler) GetMyTransactions(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	role := c.Query("role")
+	if role != "" && role != services.TransactionRoleBuyer && role != services.TransactionRoleSeller {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "role must be one of: buyer, seller",
+		})
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", services.TransactionPending, services.TransactionCompleted, services.TransactionCancelled, services.TransactionDisputed:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "status must be one of: pending, completed, cancelled, disputed",
+		})
+	}
+
+	limit, offset := middleware.ParsePagination(c)
+
+	transactions, total, err := h.transactionService.GetUserTransactions(c.Context(), authenticatedUserID.(string), role, status, limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve transactions",
+		})
+	}
+
+	c.Set("Cache-Control", "private, no-store")
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Data:    transactions,
+		Pagination: models.Pagination{
+			Limit:  limit,
+			Offset: offset,
+			Total:  total,
+		},
+	})
+}
+
+// GetTransaction returns one of the authenticated user's transactions
+func (h *TransactionHandler) GetTransaction(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	transaction, err := h.transactionService.GetTransaction(c.Context(), c.Params("id"), authenticatedUserID.(string))
+	if err != nil {
+		return transactionError(c, err, "Failed to retrieve transaction")
+	}
+
+	c.Set("Cache-Control", "private, no-store")
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    transaction,
+	})
+}
+
+// ConfirmTransaction is the buyer confirming a sale
+func (h *TransactionHandler) ConfirmTransaction(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	transaction, err := h.transactionService.ConfirmTransaction(c.Context(), c.Params("id"), authenticatedUserID.(string))
+	if err != nil {
+		return transactionError(c, err, "Failed to confirm transaction")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    transaction,
+		Message: "Purchase confirmed",
+	})
+}
+
+// CancelTransaction calls off a pending sale
+func (h *TransactionHandler) CancelTransaction(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	transaction, err := h.transactionService.CancelTransaction(c.Context(), c.Params("id"), authenticatedUserID.(string))
+	if err != nil {
+		return transactionError(c, err, "Failed to cancel transaction")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    transaction,
+		Message: "Transaction cancelled; the item is listed again",
+	})
+}
+
+// DisputeTransaction reports a problem with a sale
+func (h *TransactionHandler) DisputeTransaction(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	var req models.DisputeTransactionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   validationErrorMessage(err),
+		})
+	}
+
+	transaction, err := h.transactionService.DisputeTransaction(c.Context(), c.Params("id"), authenticatedUserID.(string), req.Reason)
+	if err != nil {
+		return transactionError(c, err, "Failed to dispute transaction")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    transaction,
+		Message: "Dispute recorded; a moderator will review it",
+	})
+}
+
+// transactionError maps transaction errors to responses, falling back to a 500 with the given message
+func transactionError(c *fiber.Ctx, err error, fallback string) error {
+	status := fiber.StatusInternalServerError
+	message := fallback
+
+	switch {
+	case err.Error() == "item not found":
+		status, message = fiber.StatusNotFound, "Item not found"
+	case err.Error() == "transaction not found":
+		status, message = fiber.StatusNotFound, "Transaction not found"
+	case err.Error() == "item already sold":
+		status, message = fiber.StatusConflict, "This item has already been marked as sold"
+	case err.Error() == "invalid buyer":
+		status, message = fiber.StatusBadRequest, "The buyer must be someone you have messaged"
+	case err.Error() == "transaction not pending":
+		status, message = fiber.StatusConflict, "This transaction is no longer pending"
+	case err.Error() == "transaction not disputable":
+		status, message = fiber.StatusConflict, "This transaction can no longer be disputed"
+	case strings.Contains(err.Error(), "not the item owner"):
+		status, message = fiber.StatusForbidden, "You can only sell your own items"
+	case strings.Contains(err.Error(), "only the buyer can confirm"):
+		status, message = fiber.StatusForbidden, "Only the buyer can confirm a purchase"
+	case strings.Contains(err.Error(), "unauthorized"):
+		status, message = fiber.StatusForbidden, "You are not part of this transaction"
+	}
+
+	return c.Status(status).JSON(models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+	"pesxchange-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UploadHandler struct {
+	uploadService *services.UploadService
+}
+
+func NewUploadHandler(uploadService *services.UploadService) *UploadHandler {
+	return &UploadHandler{
+		uploadService: uploadService,
+	}
+}
+
+// CreateIntent returns a signed URL the client uploads an image to directly, bypassing the API
+func (h *UploadHandler) CreateIntent(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	userID := authenticatedUserID.(string)
+
+	var req models.CreateUploadIntentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	intent, err := h.uploadService.CreateIntent(c.Context(), userID, &req)
+	if err != nil {
+		var quotaErr *services.StorageQuotaError
+		if errors.As(err, &quotaErr) {
+			return storageQuotaResponse(c, quotaErr)
+		}
+
+		switch err.Error() {
+		case "unsupported image type":
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Only JPEG, PNG and WebP images are allowed",
+			})
+		case "invalid file size":
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Size must be greater than 0",
+			})
+		case "file too large":
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(models.APIResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Images must be at most %s", formatMegabytes(h.uploadService.MaxUploadSize())),
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to create upload",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Success: true,
+		Data:    intent,
+	})
+}
+
+// CompleteUpload verifies an uploaded image and returns the URLs that can be attached to an item
+func (h *UploadHandler) CompleteUpload(c *fiber.Ctx) error {
+	uploadID := c.Params("id")
+	if uploadID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Upload ID is required",
+		})
+	}
+
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	userID := authenticatedUserID.(string)
+
+	variants, err := h.uploadService.CompleteUpload(c.Context(), userID, uploadID)
+	if err != nil {
+		if err.Error() == "upload not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Upload not found",
+			})
+		}
+		if strings.Contains(err.Error(), "unauthorized") {
+			return c.Status(fiber.StatusForbidden).JSON(models.APIResponse{
+				Success: false,
+				Error:   "You can only complete your own uploads",
+			})
+		}
+		if err.Error() == "upload not received" {
+			return c.Status(fiber.StatusConflict).JSON(models.APIResponse{
+				Success: false,
+				Error:   "The file has not been uploaded yet",
+			})
+		}
+		if strings.HasPrefix(err.Error(), "upload failed verification") {
+			errorMsg := "Upload rejected"
+			if reason := strings.TrimPrefix(err.Error(), "upload failed verification: "); reason != err.Error() {
+				errorMsg += ": " + reason
+			}
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(models.APIResponse{
+				Success: false,
+				Error:   errorMsg,
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to complete upload",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"url":      variants[utils.VariantFull],
+			"variants": variants,
+		},
+	})
+}
+
+// GetStorageUsage returns the authenticated user's image storage usage and quota
+func (h *UploadHandler) GetStorageUsage(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	userID := authenticatedUserID.(string)
+
+	usage, err := h.uploadService.GetStorageUsage(c.Context(), userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+				Success: false,
+				Error:   "User not found",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Failed to get storage usage",
+		})
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    usage,
+	})
+}
+
+// storageQuotaResponse reports an upload rejected for exceeding the user's storage quota
+func storageQuotaResponse(c *fiber.Ctx, err *services.StorageQuotaError) error {
+	errorMsg := "You have reached your image storage limit. Delete unused images or listings to free up space."
+	if err.Resource == services.QuotaResourceImages {
+		errorMsg = fmt.Sprintf("You can store at most %d images. Delete unused images or listings to free up space.", err.Limit)
+	}
+
+	return c.Status(fiber.StatusForbidden).JSON(models.APIResponse{
+		Success: false,
+		Error:   errorMsg,
+		Data: fiber.Map{
+			"reason":   "storage_quota_exceeded",
+			"resource": err.Resource,
+			"used":     err.Used,
+			"limit":    err.Limit,
+		},
+	})
+}
+
+// quotaCheckResponse reports a failed CheckQuota: over quota, or unable to check
+func quotaCheckResponse(c *fiber.Ctx, err error) error {
+	var quotaErr *services.StorageQuotaError
+	if errors.As(err, &quotaErr) {
+		return storageQuotaResponse(c, quotaErr)
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+		Success: false,
+		Error:   "Failed to check storage quota",
+	})
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
-	"fmt"
+	"strings"
+
+	"pesxchange-backend/config"
 	"pesxchange-backend/models"
 	"pesxchange-backend/services"
+	"pesxchange-backend/utils"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -11,17 +14,20 @@ import (
 
 type UserHandler struct {
 	userService *services.UserService
+	cfg         *config.Config
 	validator   *validator.Validate
 }
 
 func NewUserHandler(userService *services.UserService) *UserHandler {
 	return &UserHandler{
 		userService: userService,
-		validator:   validator.New(),
+		cfg:         config.Load(),
+		validator:   utils.NewValidator(),
 	}
 }
 
-// GetProfile gets user profile by ID
+// GetProfile gets user profile by ID. The user themselves and admins get the full record;
+// everyone else gets the public projection.
 func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
 	userID := c.Params("id")
 	if userID == "" {
@@ -31,28 +37,106 @@ func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
 		})
 	}
 	
-	user, err := h.userService.GetUserByID(c.Context(), userID)
-	if err != nil {
-		if err.Error() == "user not found" {
-			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
-				Success: false,
-				Error:   "User not found",
-			})
+	// The response depends on who is asking
+	c.Vary(fiber.HeaderAuthorization)
+	
+	viewerID, _ := c.Locals("userID").(string)
+	if viewerID == userID || h.cfg.IsAdmin(viewerID) {
+		user, err := h.userService.GetUserByID(c.Context(), userID)
+		if err != nil {
+			return profileError(c, err)
 		}
 		
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Success: false,
-			Error:   "Failed to retrieve user profile",
+		c.Set("Cache-Control", "private, no-store")
+		return c.JSON(models.APIResponse{
+			Success: true,
+			Data:    user,
 		})
 	}
 	
+	profile, err := h.userService.GetPublicProfile(c.Context(), userID)
+	if err != nil {
+		return profileError(c, err)
+	}
+	
 	// Set cache headers for profile data (5 minutes)
 	c.Set("Cache-Control", "public, max-age=300")
-	c.Set("ETag", fmt.Sprintf("\"%s-%d\"", userID, user.UpdatedAt.Unix()))
 	
 	return c.JSON(models.APIResponse{
 		Success: true,
-		Data:    user,
+		Data:    profile,
+	})
+}
+
+// GetMyProfile returns the authenticated user's full profile
+func (h *UserHandler) GetMyProfile(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+	
+	user, err := h.userService.GetUserByID(c.Context(), authenticatedUserID.(string))
+	if err != nil {
+		return profileError(c, err)
+	}
+	
+	c.Set("Cache-Control", "private, no-store")
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data: fiber.Map{
+			"profile":    user,
+			"visibility": services.ProfileVisibilityOf(user),
+		},
+	})
+}
+
+// UpdateProfileVisibility changes which optional fields other users can see
+func (h *UserHandler) UpdateProfileVisibility(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+	
+	var req models.UpdateProfileVisibilityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+	
+	visibility, err := h.userService.UpdateProfileVisibility(c.Context(), authenticatedUserID.(string), &req)
+	if err != nil {
+		return profileError(c, err)
+	}
+	
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    visibility,
+		Message: "Profile visibility updated successfully",
+	})
+}
+
+// profileError maps user lookup errors to responses
+func profileError(c *fiber.Ctx, err error) error {
+	if err.Error() == "user not found" {
+		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
+			Success: false,
+			Error:   "User not found",
+		})
+	}
+	
+	return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
+		Success: false,
+		Error:   "Failed to retrieve user profile",
 	})
 }
 
@@ -84,22 +168,30 @@ func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
 		})
 	}
 	
-	var updates map[string]interface{}
-	if err := c.BodyParser(&updates); err != nil {
+	var req models.UpdateProfileRequest
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
 			Success: false,
 			Error:   "Invalid request body",
 		})
 	}
 	
-	// Remove protected fields that shouldn't be updated directly
-	delete(updates, "id")
-	delete(updates, "srn")
-	delete(updates, "created_at")
-	delete(updates, "verified")
-	delete(updates, "rating")
+	// Validate trimmed values
+	if req.Nickname != nil {
+		*req.Nickname = strings.TrimSpace(*req.Nickname)
+	}
+	if req.Bio != nil {
+		*req.Bio = strings.TrimSpace(*req.Bio)
+	}
+	
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   validationErrorMessage(err),
+		})
+	}
 	
-	user, err := h.userService.UpdateUserProfile(c.Context(), userID, updates)
+	user, err := h.userService.UpdateUserProfile(c.Context(), userID, &req)
 	if err != nil {
 		if err.Error() == "user not found" {
 			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
@@ -107,6 +199,12 @@ func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
 				Error:   "User not found",
 			})
 		}
+		if err.Error() == "invalid avatar URL" {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Success: false,
+				Error:   "Avatar must be an uploaded image",
+			})
+		}
 		
 		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
 			Success: false,
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"fmt"
+
+	"pesxchange-backend/models"
+	"pesxchange-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetVacation turns on vacation mode for the authenticated user, hiding their listings until
+// they turn it off or the optional return date passes
+func (h *UserHandler) SetVacation(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	var req models.SetVacationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Success: false,
+			Error:   validationErrorMessage(err),
+		})
+	}
+
+	user, err := h.userService.StartVacation(c.Context(), authenticatedUserID.(string), &req)
+	if err != nil {
+		return vacationError(c, err, "Failed to turn on vacation mode")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    user,
+		Message: "Vacation mode on; your listings are hidden until you're back",
+	})
+}
+
+// EndVacation turns off vacation mode for the authenticated user and lists their items again
+func (h *UserHandler) EndVacation(c *fiber.Ctx) error {
+	// Get authenticated user ID from JWT middleware
+	authenticatedUserID := c.Locals("userID")
+	if authenticatedUserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+			Success: false,
+			Error:   "Authentication required",
+		})
+	}
+
+	user, err := h.userService.EndVacation(c.Context(), authenticatedUserID.(string))
+	if err != nil {
+		return vacationError(c, err, "Failed to turn off vacation mode")
+	}
+
+	return c.JSON(models.APIResponse{
+		Success: true,
+		Data:    user,
+		Message: "Welcome back; your listings are visible again",
+	})
+}
+
+// vacationError maps vacation mode errors to responses, falling back to a 500 with the given message
+func vacationError(c *fiber.Ctx, err error, fallback string) error {
+	status := fiber.StatusInternalServerError
+	message := fallback
+
+	switch err.Error() {
+	case "user not found":
+		status, message = fiber.StatusNotFound, "User not found"
+	case "return date must be in the future":
+		status, message = fiber.StatusBadRequest, "Return date must be in the future"
+	case "return date too far ahead":
+		status, message = fiber.StatusBadRequest, fmt.Sprintf("Return date must be within %d days", services.MaxVacationDays)
+	case "message blocked by screening":
+		status, message = fiber.StatusBadRequest, "Your away message contains content that isn't allowed"
+	}
+
+	return c.Status(status).JSON(models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validationErrorMessage turns validator errors into a user-friendly message
+func validationErrorMessage(err error) string {
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return "Invalid request"
+	}
+
+	var errorMsgs []string
+	for _, err := range validationErrors {
+		switch err.Tag() {
+		case "required":
+			errorMsgs = append(errorMsgs, err.Field()+" is required")
+		case "min":
+			errorMsgs = append(errorMsgs, err.Field()+" must be at least "+err.Param()+" characters long")
+		case "max":
+			errorMsgs = append(errorMsgs, err.Field()+" must be less than "+err.Param()+" characters")
+		case "gt":
+			errorMsgs = append(errorMsgs, err.Field()+" must be greater than "+err.Param())
+		case "oneof":
+			errorMsgs = append(errorMsgs, err.Field()+" must be one of: "+strings.ReplaceAll(err.Param(), "'", ""))
+		case "url":
+			errorMsgs = append(errorMsgs, err.Field()+" must be a valid URL")
+		case "nickname":
+			errorMsgs = append(errorMsgs, err.Field()+" may only contain letters, numbers, spaces, _ . and -, and must start with a letter or number")
+		case "profile_location":
+			errorMsgs = append(errorMsgs, err.Field()+" must be one of the listed campus locations")
+		default:
+			errorMsgs = append(errorMsgs, err.Field()+" is invalid")
+		}
+	}
+	return strings.Join(errorMsgs, ", ")
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pesxchange-backend/config"
+	"pesxchange-backend/services"
+)
+
+// AccountDeletion deletes accounts whose deletion grace period has ended
+func AccountDeletion(cfg *config.Config) Job {
+	accountService := services.NewAccountService()
+
+	return Job{
+		Name:     "account-deletion",
+		Interval: time.Duration(cfg.AccountDeletionIntervalMinutes) * time.Minute,
+		Run: func(ctx context.Context) error {
+			purged, err := accountService.PurgeDueAccounts(ctx)
+			if err != nil {
+				return err
+			}
+			if purged > 0 {
+				log.Printf("Account deletion: deleted %d accounts", purged)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Job is a task run periodically in the background
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Start runs each job on its own interval for the life of the process.
+// Jobs with a non-positive interval are disabled.
+func Start(jobs ...Job) {
+	for _, job := range jobs {
+		if job.Interval <= 0 {
+			log.Printf("Job %s disabled", job.Name)
+			continue
+		}
+		go run(job)
+	}
+}
+
+func run(job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		// Bound each run so a hung request can't stall the job forever
+		ctx, cancel := context.WithTimeout(context.Background(), job.Interval)
+		if err := job.Run(ctx); err != nil {
+			log.Printf("Job %s failed: %v", job.Name, err)
+		}
+		cancel()
+	}
+}
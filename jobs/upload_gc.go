@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pesxchange-backend/config"
+	"pesxchange-backend/services"
+)
+
+// UploadGC deletes uploads that no item references once the grace period has passed
+func UploadGC(cfg *config.Config) Job {
+	uploadService := services.NewUploadService()
+	grace := time.Duration(cfg.UploadGCGraceHours) * time.Hour
+
+	return Job{
+		Name:     "upload-gc",
+		Interval: time.Duration(cfg.UploadGCIntervalMinutes) * time.Minute,
+		Run: func(ctx context.Context) error {
+			report, err := uploadService.CollectGarbage(ctx, grace, false)
+			if err != nil {
+				return err
+			}
+			if len(report.Orphans) > 0 || len(report.Failures) > 0 {
+				log.Printf("Upload GC: scanned %d uploads, found %d orphaned, deleted %d objects, %d failures",
+					report.Scanned, len(report.Orphans), report.DeletedObjects, len(report.Failures))
+			}
+			return nil
+		},
+	}
+}
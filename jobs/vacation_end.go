@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pesxchange-backend/config"
+	"pesxchange-backend/services"
+)
+
+// VacationEnd takes sellers out of vacation mode once their return date has passed
+func VacationEnd(cfg *config.Config) Job {
+	userService := services.NewUserService()
+
+	return Job{
+		Name:     "vacation-end",
+		Interval: time.Duration(cfg.VacationCheckIntervalMinutes) * time.Minute,
+		Run: func(ctx context.Context) error {
+			ended, err := userService.EndExpiredVacations(ctx)
+			if ended > 0 {
+				log.Printf("Vacation end: ended %d vacations", ended)
+			}
+			return err
+		},
+	}
+}
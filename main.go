@@ -2,13 +2,17 @@ package main
 
 import (
 	"log"
+	"os"
 	"strings"
 	"time"
 
 	"pesxchange-backend/config"
 	"pesxchange-backend/database"
+	"pesxchange-backend/jobs"
 	"pesxchange-backend/middleware"
 	"pesxchange-backend/routes"
+	"pesxchange-backend/services"
+	"pesxchange-backend/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/helmet/v2"
@@ -23,6 +27,17 @@ func main() {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
+	// Initialize object storage
+	if err := storage.Initialize(cfg); err != nil {
+		log.Fatal("Failed to initialize object storage:", err)
+	}
+
+	// Subcommands (e.g. "migrate-images") run a maintenance task and exit
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1])
+		return
+	}
+
 	// Initialize Fiber app with balanced settings for development and production
 	readTimeout := 30 * time.Second  // Default for production
 	writeTimeout := 30 * time.Second // Default for production
@@ -38,7 +53,7 @@ func main() {
 		ReadTimeout:       readTimeout,
 		WriteTimeout:      writeTimeout,
 		IdleTimeout:       120 * time.Second,   // 2 minutes - longer idle timeout
-		BodyLimit:         2 * 1024 * 1024, // 2MB - security limit
+		BodyLimit:         services.MaxRequestBodySize, // 2MB - security limit; larger images go through signed direct uploads
 		DisableKeepalive:  false, // Keep connections alive
 		ServerHeader:      "",    // Hide server information
 		AppName:           "PesXChange API",
@@ -154,6 +169,21 @@ func main() {
 	routes.SetupItemRoutes(apiGroup)
 	routes.SetupMessageRoutes(apiGroup)
 	routes.SetupProfileRoutes(apiGroup)
+	routes.SetupHandleRoutes(apiGroup)
+	routes.SetupReviewRoutes(apiGroup)
+	routes.SetupTransactionRoutes(apiGroup)
+	routes.SetupFeedRoutes(apiGroup)
+	routes.SetupMeRoutes(apiGroup)
+	routes.SetupUploadRoutes(apiGroup)
+	routes.SetupAdminRoutes(apiGroup)
+	routes.SetupStorageRoutes(app)
+
+	// Background jobs
+	jobs.Start(
+		jobs.UploadGC(cfg),
+		jobs.AccountDeletion(cfg),
+		jobs.VacationEnd(cfg),
+	)
 
 	// Start server
 	port := cfg.Port
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"pesxchange-backend/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// deletedAccounts remembers accounts found to be deleted; deletion is permanent, so
+// they never need checking again
+var deletedAccounts sync.Map
+
+// isDeletedAccount reports whether userID belongs to an account that has been anonymised.
+// Tokens issued before the purge stay valid until they expire, so they're checked here.
+func isDeletedAccount(userID string) (bool, error) {
+	if _, ok := deletedAccounts.Load(userID); ok {
+		return true, nil
+	}
+
+	var users []struct {
+		DeletedAt *string `json:"deleted_at"`
+	}
+	data, _, err := database.GetClient().From("user_profiles").
+		Select("deleted_at", "exact", false).
+		Eq("id", userID).
+		Execute()
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check account: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &users); err != nil {
+		return false, fmt.Errorf("failed to parse account: %w", err)
+	}
+
+	if len(users) == 0 || users[0].DeletedAt != nil {
+		deletedAccounts.Store(userID, true)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// isReadOnlyRequest reports whether a request's method can't change data
+func isReadOnlyRequest(c *fiber.Ctx) bool {
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return true
+	}
+	return false
+}
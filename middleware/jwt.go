@@ -75,6 +75,23 @@ func JWTAuth() fiber.Handler {
 				})
 			}
 			
+			// Deleted accounts can't act with tokens issued before they were purged
+			if !isReadOnlyRequest(c) {
+				deleted, err := isDeletedAccount(claims.UserID)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"error":   "Failed to verify account",
+						"success": false,
+					})
+				}
+				if deleted {
+					return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+						"error":   "This account has been deleted",
+						"success": false,
+					})
+				}
+			}
+			
 			// Set user information in context
 			c.Locals("userID", claims.UserID)
 			c.Locals("userSRN", claims.SRN)
@@ -126,4 +143,21 @@ func OptionalJWTAuth() fiber.Handler {
 		
 		return c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// RequireAdmin restricts a route to users listed in ADMIN_USER_IDS; use after JWTAuth
+func RequireAdmin() fiber.Handler {
+	cfg := config.Load()
+	
+	return func(c *fiber.Ctx) error {
+		userID, _ := c.Locals("userID").(string)
+		if !cfg.IsAdmin(userID) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   "Admin access required",
+				"success": false,
+			})
+		}
+		
+		return c.Next()
+	}
+}
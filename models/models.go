@@ -56,6 +56,121 @@ type User struct {
 	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
 	LastLogin   *time.Time `json:"last_login" db:"last_login"`
 	Nickname    string     `json:"nickname" db:"nickname"`
+
+	// Set by admins; trusted sellers get the highest storage quota
+	TrustedSeller bool `json:"trusted_seller" db:"trusted_seller"`
+
+	// Which optional fields other users see; nil means the defaults
+	ProfileVisibility *ProfileVisibility `json:"profile_visibility,omitempty" db:"profile_visibility"`
+
+	// Unique lowercase @handle, unset until the user picks one
+	Handle          *string    `json:"handle,omitempty" db:"handle"`
+	HandleChangedAt *time.Time `json:"handle_changed_at,omitempty" db:"handle_changed_at"`
+
+	// Number of reviews behind Rating, which is their Bayesian average
+	ReviewCount int `json:"review_count" db:"review_count"`
+
+	// Set while a deletion request is pending; DeletedAt once the account has been anonymised
+	DeletionRequestedAt  *time.Time `json:"deletion_requested_at,omitempty" db:"deletion_requested_at"`
+	DeletionScheduledFor *time.Time `json:"deletion_scheduled_for,omitempty" db:"deletion_scheduled_for"`
+	DeletedAt            *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+
+	// Vacation mode: listings are hidden while set; VacationUntil is the optional return date
+	VacationStartedAt *time.Time `json:"vacation_started_at,omitempty" db:"vacation_started_at"`
+	VacationUntil     *time.Time `json:"vacation_until,omitempty" db:"vacation_until"`
+	VacationMessage   string     `json:"vacation_message,omitempty" db:"vacation_message"`
+
+	// Set when PESU data shows the student has graduated; alumni have a cap on active listings
+	AlumniSince *time.Time `json:"alumni_since" db:"alumni_since"`
+}
+
+// ProfileVisibility controls which optional fields appear on a user's public profile.
+// Email, phone, SRN and PRN are never shown to other users.
+type ProfileVisibility struct {
+	ShowName     bool `json:"show_name"`
+	ShowBranch   bool `json:"show_branch"`
+	ShowSemester bool `json:"show_semester"`
+	ShowCampus   bool `json:"show_campus"`
+}
+
+// UpdateProfileRequest represents the profile fields a user can edit; omitted fields are
+// unchanged. Everything else comes from PESU or is managed by the server.
+type UpdateProfileRequest struct {
+	Nickname  *string `json:"nickname" validate:"omitnil,min=3,max=30,nickname"`
+	Bio       *string `json:"bio" validate:"omitempty,max=500"`
+	AvatarURL *string `json:"avatar_url" validate:"omitnil,max=2048"` // Must point at our storage; empty removes it
+	Location  *string `json:"location" validate:"omitnil,profile_location"`
+}
+
+// HandleRedirect points a user's previous handle at them for a while after a change - matches handle_redirects table
+type HandleRedirect struct {
+	Handle    string    `json:"handle" db:"handle"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// HandleAvailability reports whether a handle can be claimed
+type HandleAvailability struct {
+	Handle    string `json:"handle"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"` // invalid, reserved, blocked or taken
+}
+
+// SetHandleRequest claims or changes the authenticated user's handle
+type SetHandleRequest struct {
+	Handle string `json:"handle" validate:"required"`
+}
+
+// Vacation is the away banner shown on a seller's profile and items
+type Vacation struct {
+	StartedAt time.Time  `json:"started_at"`
+	Until     *time.Time `json:"until,omitempty"`
+	Message   string     `json:"message,omitempty"`
+}
+
+// SetVacationRequest turns on vacation mode, or changes the return date and message
+type SetVacationRequest struct {
+	Until   *time.Time `json:"until"` // Omit to stay away until vacation mode is turned off
+	Message string     `json:"message" validate:"max=300"`
+}
+
+// UpdateProfileVisibilityRequest changes visibility settings; omitted fields are unchanged
+type UpdateProfileVisibilityRequest struct {
+	ShowName     *bool `json:"show_name"`
+	ShowBranch   *bool `json:"show_branch"`
+	ShowSemester *bool `json:"show_semester"`
+	ShowCampus   *bool `json:"show_campus"`
+}
+
+// PublicProfile is the projection of a user shown to everyone except the user and admins
+type PublicProfile struct {
+	ID            string       `json:"id"`
+	Handle        string       `json:"handle,omitempty"`
+	Nickname      string       `json:"nickname"`
+	Name          string       `json:"name,omitempty"`
+	AvatarURL     string       `json:"avatar_url"`
+	Bio           string       `json:"bio"`
+	Rating        float64      `json:"rating"`
+	ReviewCount   int          `json:"review_count"`
+	Verified      bool         `json:"verified"`
+	TrustedSeller bool         `json:"trusted_seller"`
+	Alumni        bool         `json:"alumni"`
+	Branch        string       `json:"branch,omitempty"`
+	Semester      string       `json:"semester,omitempty"`
+	Campus        string       `json:"campus,omitempty"`
+	Location      string       `json:"location"`
+	MemberSince   time.Time    `json:"member_since"`
+	Stats         ProfileStats `json:"stats"`
+	Vacation      *Vacation    `json:"vacation,omitempty"` // Set while the user is away
+}
+
+// ProfileStats summarises a user's listings and followers
+type ProfileStats struct {
+	ActiveListings int `json:"active_listings"`
+	TotalListings  int `json:"total_listings"`
+	Followers      int `json:"followers"`
+	Following      int `json:"following"`
 }
 
 // Item represents an item for sale - matches items table exactly
@@ -77,12 +192,46 @@ type Item struct {
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 	Category    string    `json:"category" db:"category"`
 	
+	// Structured images in display order; Images is kept in sync for older clients
+	ImageDetails []ItemImage `json:"image_details" db:"image_details"`
+	
+	// Set while the seller is on vacation; such items are left out of listings and search
+	SellerAway bool `json:"seller_away" db:"seller_away"`
+	
+	// Set when the seller marks the item sold to a buyer
+	BuyerID *string    `json:"buyer_id,omitempty" db:"buyer_id"`
+	SoldAt  *time.Time `json:"sold_at,omitempty" db:"sold_at"`
+	
 	// Legacy field for backward compatibility with frontend
 	ImageURLs   []string  `json:"image_urls,omitempty"`
 	Categories  []string  `json:"categories,omitempty"`
 	
 	// Joined fields
-	Seller *User `json:"seller,omitempty"`
+	Seller         *User     `json:"seller,omitempty"`
+	SellerVacation *Vacation `json:"seller_vacation,omitempty"` // Away banner for the item page
+}
+
+// ItemImage is one photo on an item - stored in the items.image_details column
+type ItemImage struct {
+	ID       string            `json:"id"`
+	URL      string            `json:"url"`
+	Variants map[string]string `json:"variants"` // URL of each size, keyed by variant name
+	Order    int               `json:"order"`    // Position on the listing; 0 is the cover image
+	Caption  string            `json:"caption"`
+	Width    int               `json:"width,omitempty"`
+	Height   int               `json:"height,omitempty"`
+	Blurhash string            `json:"blurhash,omitempty"`
+}
+
+// ReorderItemImagesRequest lists every image ID on an item in the new order
+type ReorderItemImagesRequest struct {
+	ImageIDs []string `json:"image_ids" validate:"required,min=1"`
+}
+
+// UpdateItemImageRequest replaces an image's file and/or caption
+type UpdateItemImageRequest struct {
+	URL     *string `json:"url" validate:"omitempty,url"`
+	Caption *string `json:"caption" validate:"omitempty,max=200"`
 }
 
 // CreateItemRequest represents item creation request - matches Node.js API
@@ -91,7 +240,7 @@ type CreateItemRequest struct {
 	Description string   `json:"description" validate:"required,min=10,max=1000"`
 	Price       float64  `json:"price" validate:"required,gt=0"`
 	Location    string   `json:"location" validate:"required,min=3,max=200"`
-	Condition   string   `json:"condition" validate:"required,oneof=New Like New Good Fair Poor"`
+	Condition   string   `json:"condition" validate:"required,oneof=New 'Like New' Good Fair Poor"`
 	Category    string   `json:"category" validate:"max=50"`
 	Images      []string `json:"images"`
 	SellerID    string   `json:"seller_id" validate:"required"`
@@ -99,6 +248,18 @@ type CreateItemRequest struct {
 	Views       *int     `json:"views"`
 }
 
+// UpdateItemRequest represents the request to update an item; omitted fields are unchanged
+type UpdateItemRequest struct {
+	Title       *string  `json:"title" validate:"omitnil,min=3,max=100"`
+	Description *string  `json:"description" validate:"omitnil,min=10,max=1000"`
+	Price       *float64 `json:"price" validate:"omitnil,gt=0"`
+	Location    *string  `json:"location" validate:"omitnil,min=3,max=200"`
+	Condition   *string  `json:"condition" validate:"omitnil,oneof=New 'Like New' Good Fair Poor"`
+	Category    *string  `json:"category" validate:"omitnil,max=50"`
+	Images      []string `json:"images"` // Replaces all images when present
+	IsAvailable *bool    `json:"is_available"`
+}
+
 // Message represents a chat message
 type Message struct {
 	ID         string    `json:"id" db:"id"`
@@ -108,6 +269,13 @@ type Message struct {
 	Message    string    `json:"message" db:"message" validate:"required,min=1,max=1000"`
 	IsRead     bool      `json:"is_read" db:"is_read"`
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	EditedAt   *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+	UnsentAt   *time.Time `json:"unsent_at,omitempty" db:"unsent_at"`
+	Warning    string     `json:"warning,omitempty" db:"warning"` // Set when screening flagged the message for the recipient
+	
+	// Per-side "delete for me" flags
+	SenderDeleted   bool `json:"sender_deleted,omitempty" db:"sender_deleted"`
+	ReceiverDeleted bool `json:"receiver_deleted,omitempty" db:"receiver_deleted"`
 	
 	// Legacy field for backward compatibility
 	Content    string    `json:"content,omitempty"`
@@ -126,6 +294,200 @@ type SendMessageRequest struct {
 	Message    string `json:"message" validate:"required,min=1,max=1000"`
 }
 
+// EditMessageRequest represents a message edit request
+type EditMessageRequest struct {
+	Message string `json:"message" validate:"required,min=1,max=1000"`
+}
+
+// MessageEdit represents a previous version of an edited message - matches message_edits table
+type MessageEdit struct {
+	ID              string    `json:"id" db:"id"`
+	MessageID       string    `json:"message_id" db:"message_id"`
+	PreviousMessage string    `json:"previous_message" db:"previous_message"`
+	EditedAt        time.Time `json:"edited_at" db:"edited_at"`
+}
+
+// ConversationSettings represents one user's archive/mute state for a conversation - matches conversation_settings table
+type ConversationSettings struct {
+	UserID      string     `json:"user_id" db:"user_id"`
+	OtherUserID string     `json:"other_user_id" db:"other_user_id"`
+	ArchivedAt  *time.Time `json:"archived_at" db:"archived_at"`
+	Muted       bool       `json:"muted" db:"muted"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// UpdateConversationRequest represents a request to archive/unarchive or mute/unmute a conversation
+type UpdateConversationRequest struct {
+	Archived *bool `json:"archived"`
+	Muted    *bool `json:"muted"`
+}
+
+// MessageSearchGroup holds search hits from one conversation
+type MessageSearchGroup struct {
+	OtherUserID string             `json:"other_user_id"`
+	OtherUser   *User              `json:"other_user,omitempty"`
+	Hits        []MessageSearchHit `json:"hits"`
+}
+
+// MessageSearchHit is a message matching a search, with a highlighted snippet and optional surrounding messages
+type MessageSearchHit struct {
+	Message      Message       `json:"message"`
+	Snippet      string        `json:"snippet"`
+	SnippetParts []SnippetPart `json:"snippet_parts"`
+	Before       []Message     `json:"before,omitempty"` // Oldest first
+	After        []Message     `json:"after,omitempty"`  // Oldest first
+}
+
+// SnippetPart is a run of snippet text, with Match set on the parts matching the query
+type SnippetPart struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match"`
+}
+
+// MessageScreeningHit records a screening rule matching a message - matches message_screening_hits table
+type MessageScreeningHit struct {
+	ID          string    `json:"id" db:"id"`
+	MessageID   *string   `json:"message_id" db:"message_id"` // Nil when the message was blocked
+	SenderID    string    `json:"sender_id" db:"sender_id"`
+	ReceiverID  string    `json:"receiver_id" db:"receiver_id"`
+	RuleID      string    `json:"rule_id" db:"rule_id"`
+	Action      string    `json:"action" db:"action"`
+	MatchedText string    `json:"matched_text" db:"matched_text"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Upload represents a direct-to-storage upload - matches uploads table
+type Upload struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      string     `json:"user_id" db:"user_id"`
+	Bucket      string     `json:"bucket" db:"bucket"`
+	ObjectKey   string     `json:"object_key" db:"object_key"`
+	ContentType string     `json:"content_type" db:"content_type"`
+	Size        int64      `json:"size" db:"size"`
+	Status      string     `json:"status" db:"status"`
+	URL         *string    `json:"url,omitempty" db:"url"`
+	PHash       *string    `json:"phash,omitempty" db:"phash"`             // Perceptual hash, hex
+	PHashBands  []int      `json:"phash_bands,omitempty" db:"phash_bands"` // See utils.HashBands
+	Width       *int       `json:"width,omitempty" db:"width"`
+	Height      *int       `json:"height,omitempty" db:"height"`
+	Blurhash    *string    `json:"blurhash,omitempty" db:"blurhash"`
+	StoredBytes int64      `json:"stored_bytes" db:"stored_bytes"` // All processed variants; counts toward the quota
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateUploadIntentRequest represents a request for a signed upload URL
+type CreateUploadIntentRequest struct {
+	ContentType string `json:"content_type" validate:"required"`
+	Size        int64  `json:"size" validate:"required,gt=0"`
+}
+
+// UploadIntent is returned to the client to upload a file directly to storage
+type UploadIntent struct {
+	UploadID  string            `json:"upload_id"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"` // Headers the client must send with the upload
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// ImageModerationFlag is a listing image that nearly matches another seller's upload - matches image_moderation_flags table
+type ImageModerationFlag struct {
+	ID              string     `json:"id" db:"id"`
+	ItemID          string     `json:"item_id" db:"item_id"`
+	SellerID        string     `json:"seller_id" db:"seller_id"`
+	ImageURL        string     `json:"image_url" db:"image_url"`
+	PHash           string     `json:"phash" db:"phash"`
+	MatchedUploadID *string    `json:"matched_upload_id" db:"matched_upload_id"`
+	MatchedUserID   *string    `json:"matched_user_id" db:"matched_user_id"`
+	MatchedURL      *string    `json:"matched_url" db:"matched_url"`
+	Distance        int        `json:"distance" db:"distance"` // Hamming distance between the hashes
+	Status          string     `json:"status" db:"status"`
+	ReviewedBy      *string    `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ReviewImageFlagRequest represents a moderator's decision on a flagged image
+type ReviewImageFlagRequest struct {
+	Status string `json:"status" validate:"required,oneof=dismissed banned"`
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// BannedImageHash is a banned image's perceptual hash - matches banned_image_hashes table
+type BannedImageHash struct {
+	PHash     string    `json:"phash" db:"phash"`
+	Reason    string    `json:"reason" db:"reason"`
+	BannedBy  *string   `json:"banned_by" db:"banned_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// OrphanedUpload is an upload no item references, found by upload garbage collection
+type OrphanedUpload struct {
+	UploadID  string    `json:"upload_id"`
+	UserID    string    `json:"user_id"`
+	Status    string    `json:"status"`
+	URL       *string   `json:"url,omitempty"`
+	Keys      []string  `json:"keys"` // Object keys that are (or would be) deleted
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UploadGCReport summarises an upload garbage collection run
+type UploadGCReport struct {
+	DryRun         bool             `json:"dry_run"`
+	GracePeriod    string           `json:"grace_period"`
+	Scanned        int              `json:"scanned"`
+	Orphans        []OrphanedUpload `json:"orphans"`
+	DeletedObjects int              `json:"deleted_objects"`
+	Failures       []string         `json:"failures"`
+}
+
+// StorageUsage reports a user's image storage against their quota. Limits of 0 are unlimited.
+type StorageUsage struct {
+	Tier        string `json:"tier"` // standard, verified or trusted
+	Bytes       int64  `json:"bytes"`
+	Images      int    `json:"images"`
+	BytesLimit  int64  `json:"bytes_limit"`
+	ImagesLimit int    `json:"images_limit"`
+}
+
+// SetTrustedSellerRequest marks a user as a trusted seller or removes the mark
+type SetTrustedSellerRequest struct {
+	Trusted bool `json:"trusted"`
+}
+
+// ImageMigration is a run of the legacy base64 image migration - matches image_migrations table
+type ImageMigration struct {
+	ID              string     `json:"id" db:"id"`
+	Status          string     `json:"status" db:"status"`
+	Cursor          *string    `json:"cursor" db:"cursor"` // Last item ID processed
+	ItemsScanned    int        `json:"items_scanned" db:"items_scanned"`
+	ItemsUpdated    int        `json:"items_updated" db:"items_updated"`
+	ImagesConverted int        `json:"images_converted" db:"images_converted"`
+	ImagesFailed    int        `json:"images_failed" db:"images_failed"`
+	LastError       *string    `json:"last_error,omitempty" db:"last_error"`
+	StartedAt       time.Time  `json:"started_at" db:"started_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// ImageMigrationFailure records an image the migration could not convert - matches image_migration_failures table
+type ImageMigrationFailure struct {
+	ID          string    `json:"id" db:"id"`
+	MigrationID string    `json:"migration_id" db:"migration_id"`
+	ItemID      string    `json:"item_id" db:"item_id"`
+	ImageIndex  int       `json:"image_index" db:"image_index"`
+	Error       string    `json:"error" db:"error"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// ImageMigrationStatus is a migration run with its per-item failures
+type ImageMigrationStatus struct {
+	Migration *ImageMigration         `json:"migration"`
+	Failures  []ImageMigrationFailure `json:"failures"`
+}
+
 // Chat represents a conversation between two users
 type Chat struct {
 	ID           string    `json:"id"`
@@ -134,11 +496,147 @@ type Chat struct {
 	LastMessage  *Message  `json:"last_message"`
 	UnreadCount  int       `json:"unread_count"`
 	UpdatedAt    time.Time `json:"updated_at"`
+	Archived     bool      `json:"archived"`
+	Muted        bool      `json:"muted"`
 	
 	// Joined fields
 	OtherUser *User `json:"other_user,omitempty"`
 }
 
+// UserBlock represents one user blocking another - matches user_blocks table
+type UserBlock struct {
+	ID        string    `json:"id" db:"id"`
+	BlockerID string    `json:"blocker_id" db:"blocker_id"`
+	BlockedID string    `json:"blocked_id" db:"blocked_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	// Joined fields
+	BlockedUser *User `json:"blocked_user,omitempty"`
+}
+
+// Review is one party's rating of the other after a sale - matches reviews table
+type Review struct {
+	ID            string     `json:"id" db:"id"`
+	ItemID        string     `json:"item_id" db:"item_id"`
+	ReviewerID    string     `json:"reviewer_id" db:"reviewer_id"`
+	RevieweeID    string     `json:"reviewee_id" db:"reviewee_id"`
+	ReviewerRole  string     `json:"reviewer_role" db:"reviewer_role"` // buyer or seller
+	TransactionID *string    `json:"transaction_id,omitempty" db:"transaction_id"`
+	Rating        int        `json:"rating" db:"rating"`
+	Text          string     `json:"text" db:"text"`
+	Reply         *string    `json:"reply,omitempty" db:"reply"` // The seller's public response to a buyer's review
+	RepliedAt     *time.Time `json:"replied_at,omitempty" db:"replied_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+
+	// Joined fields
+	Reviewer *User `json:"reviewer,omitempty"`
+}
+
+// CreateReviewRequest reviews the other party of a sold item
+type CreateReviewRequest struct {
+	ItemID string `json:"item_id" validate:"required,uuid"`
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Text   string `json:"text" validate:"max=1000"`
+}
+
+// ReplyToReviewRequest is a seller's public reply to a review
+type ReplyToReviewRequest struct {
+	Reply string `json:"reply" validate:"required,max=500"`
+}
+
+// MarkItemSoldRequest starts a transaction selling an item to one of the seller's conversation partners
+type MarkItemSoldRequest struct {
+	BuyerID    string     `json:"buyer_id" validate:"required,uuid"`
+	FinalPrice *float64   `json:"final_price" validate:"omitnil,gt=0"` // Defaults to the listed price
+	HandoverAt *time.Time `json:"handover_at"`
+}
+
+// Transaction records the sale of an item to a buyer - matches transactions table
+type Transaction struct {
+	ID            string     `json:"id" db:"id"`
+	ItemID        string     `json:"item_id" db:"item_id"`
+	SellerID      string     `json:"seller_id" db:"seller_id"`
+	BuyerID       string     `json:"buyer_id" db:"buyer_id"`
+	Status        string     `json:"status" db:"status"` // pending, completed, cancelled or disputed
+	FinalPrice    float64    `json:"final_price" db:"final_price"`
+	HandoverAt    *time.Time `json:"handover_at,omitempty" db:"handover_at"`
+	ConfirmedAt   *time.Time `json:"confirmed_at,omitempty" db:"confirmed_at"` // When the buyer confirmed the sale
+	CancelledAt   *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	CancelledBy   *string    `json:"cancelled_by,omitempty" db:"cancelled_by"`
+	DisputedAt    *time.Time `json:"disputed_at,omitempty" db:"disputed_at"`
+	DisputedBy    *string    `json:"disputed_by,omitempty" db:"disputed_by"`
+	DisputeReason string     `json:"dispute_reason,omitempty" db:"dispute_reason"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Joined fields
+	Item  *Item `json:"item,omitempty"`
+	Other *User `json:"other_user,omitempty"` // The counterpart of the user viewing the transaction
+}
+
+// DisputeTransactionRequest flags a problem with a sale
+type DisputeTransactionRequest struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+// UserFollow represents one user following another - matches user_follows table
+type UserFollow struct {
+	FollowerID string    `json:"follower_id" db:"follower_id"`
+	FolloweeID string    `json:"followee_id" db:"followee_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// Notification is an in-app notification - matches notifications table
+type Notification struct {
+	ID        string     `json:"id" db:"id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	Type      string     `json:"type" db:"type"`
+	ActorID   *string    `json:"actor_id,omitempty" db:"actor_id"` // The user whose action caused the notification
+	ItemID    *string    `json:"item_id,omitempty" db:"item_id"`
+	Message   string     `json:"message" db:"message"`
+	ReadAt    *time.Time `json:"read_at,omitempty" db:"read_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// MarkNotificationsReadRequest marks the given notifications, or all of them, as read
+type MarkNotificationsReadRequest struct {
+	IDs []string `json:"ids" validate:"omitempty,dive,uuid"`
+	All bool     `json:"all"`
+}
+
+// ProfileHistoryEntry records one change to a PESU-sourced profile field - matches user_profile_history table
+type ProfileHistoryEntry struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Field     string    `json:"field" db:"field"`
+	OldValue  string    `json:"old_value" db:"old_value"`
+	NewValue  string    `json:"new_value" db:"new_value"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// AccountDeletionStatus describes a user's pending account deletion, if any
+type AccountDeletionStatus struct {
+	Scheduled    bool       `json:"scheduled"`
+	RequestedAt  *time.Time `json:"requested_at,omitempty"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+}
+
+// AccountExport is everything stored about a user, as returned by GET /api/me/export
+type AccountExport struct {
+	ExportedAt      time.Time             `json:"exported_at"`
+	Profile         *User                 `json:"profile"`
+	Items           []Item                `json:"items"`
+	Messages        []Message             `json:"messages"`
+	ReviewsGiven    []Review              `json:"reviews_given"`
+	ReviewsReceived []Review              `json:"reviews_received"`
+	Transactions    []Transaction         `json:"transactions"`
+	Following       []UserFollow          `json:"following"`
+	Followers       []UserFollow          `json:"followers"`
+	Blocks          []UserBlock           `json:"blocks"`
+	Notifications   []Notification        `json:"notifications"`
+	ProfileHistory  []ProfileHistoryEntry `json:"profile_history"`
+}
+
 // APIResponse represents a standard API response
 type APIResponse struct {
 	Success bool        `json:"success"`
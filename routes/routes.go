@@ -5,6 +5,7 @@ import (
 	"pesxchange-backend/handlers"
 	"pesxchange-backend/middleware"
 	"pesxchange-backend/services"
+	"pesxchange-backend/storage"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -32,34 +33,139 @@ func SetupUserRoutes(api fiber.Router) {
 	userService := services.NewUserService()
 	userHandler := handlers.NewUserHandler(userService)
 
+	blockHandler := handlers.NewBlockHandler(services.NewBlockService())
+	followHandler := handlers.NewFollowHandler(services.NewFollowService(), services.NewItemService())
+
 	users := api.Group("/users")
 	
 	// Get all users (admin only - not implemented)
 	users.Get("/", userHandler.GetAllUsers)
+	
+	// Blocking (protected)
+	users.Post("/:id/block", middleware.JWTAuth(), blockHandler.BlockUser)     // Block a user
+	users.Delete("/:id/block", middleware.JWTAuth(), blockHandler.UnblockUser) // Unblock a user
+	
+	// Following (protected)
+	users.Get("/:id/follow", middleware.JWTAuth(), followHandler.GetFollowStatus)  // Do I follow this user
+	users.Post("/:id/follow", middleware.JWTAuth(), followHandler.FollowUser)      // Follow a seller
+	users.Delete("/:id/follow", middleware.JWTAuth(), followHandler.UnfollowUser) // Unfollow a seller
+}
+
+func SetupFeedRoutes(api fiber.Router) {
+	followHandler := handlers.NewFollowHandler(services.NewFollowService(), services.NewItemService())
+
+	// Personalised feeds (protected)
+	feed := api.Group("/feed", middleware.JWTAuth())
+	
+	feed.Get("/following", followHandler.GetFollowingFeed) // New items from sellers I follow
+}
+
+func SetupMeRoutes(api fiber.Router) {
+	blockHandler := handlers.NewBlockHandler(services.NewBlockService())
+	uploadHandler := handlers.NewUploadHandler(services.NewUploadService())
+	userHandler := handlers.NewUserHandler(services.NewUserService())
+	handleHandler := handlers.NewHandleHandler(services.NewUserService())
+	transactionHandler := handlers.NewTransactionHandler(services.NewTransactionService())
+	notificationHandler := handlers.NewNotificationHandler(services.NewNotificationService())
+	accountHandler := handlers.NewAccountHandler(services.NewAccountService())
+
+	// Endpoints scoped to the authenticated user
+	me := api.Group("/me", middleware.JWTAuth())
+	
+	me.Get("/blocks", blockHandler.GetBlockedUsers)                                                     // List users I have blocked
+	me.Get("/storage", uploadHandler.GetStorageUsage)                                                   // Image storage used and quota
+	me.Get("/profile", userHandler.GetMyProfile)                                                        // My full profile and visibility settings
+	me.Put("/profile/visibility", middleware.ValidateJSON(), userHandler.UpdateProfileVisibility)       // Choose what others see on my profile
+	me.Put("/handle", middleware.ValidateJSON(), handleHandler.SetHandle)                               // Claim or change my @handle
+	me.Post("/avatar", userHandler.UploadAvatar)                                                        // Upload a new profile picture
+	me.Delete("/avatar", userHandler.DeleteAvatar)                                                      // Remove my profile picture
+	me.Put("/vacation", middleware.ValidateJSON(), userHandler.SetVacation)                             // Hide my listings while I'm away
+	me.Delete("/vacation", userHandler.EndVacation)                                                     // Show my listings again
+	me.Get("/transactions", transactionHandler.GetMyTransactions)                                       // My purchase and sales history
+	me.Get("/notifications", notificationHandler.GetNotifications)                                      // My notifications
+	me.Put("/notifications/read", middleware.ValidateJSON(), notificationHandler.MarkNotificationsRead) // Mark notifications as read
+	me.Get("/export", accountHandler.ExportData)                                                        // Download my data (?format=json|zip)
+	me.Delete("/", accountHandler.RequestDeletion)                                                      // Schedule my account for deletion
+	me.Get("/deletion", accountHandler.GetDeletionStatus)                                               // Is my account scheduled for deletion
+	me.Post("/deletion/cancel", accountHandler.CancelDeletion)                                          // Keep my account
+}
+
+// SetupStorageRoutes serves uploaded files when the local storage backend is in use
+func SetupStorageRoutes(app fiber.Router) {
+	cfg := config.Load()
+	if cfg.StorageBackend != "local" {
+		return
+	}
+
+	storageHandler := handlers.NewStorageHandler()
+	
+	app.Get(storage.LocalStorageRoute+"/:bucket/*", storageHandler.ServeLocalObject)  // Serve a stored object
+	app.Put(storage.LocalStorageRoute+"/:bucket/*", storageHandler.UploadLocalObject) // Direct upload with a signed URL
+}
+
+func SetupUploadRoutes(api fiber.Router) {
+	uploadHandler := handlers.NewUploadHandler(services.NewUploadService())
+
+	// Direct-to-storage uploads (protected)
+	uploads := api.Group("/uploads", middleware.JWTAuth())
+	
+	uploads.Post("/intent", middleware.ValidateJSON(), uploadHandler.CreateIntent) // Get a signed upload URL
+	uploads.Post("/:id/complete", uploadHandler.CompleteUpload)                    // Verify an upload and get attachable URLs
+}
+
+func SetupAdminRoutes(api fiber.Router) {
+	cfg := config.Load()
+	adminHandler := handlers.NewAdminHandler(services.NewUploadService(), services.NewImageMigrationService(), services.NewImageModerationService(), services.NewUserService(), cfg)
+
+	// Admin endpoints (protected, ADMIN_USER_IDS only)
+	admin := api.Group("/admin", middleware.JWTAuth(), middleware.RequireAdmin())
+	
+	admin.Get("/uploads/orphans", adminHandler.GetOrphanedUploads)  // Dry-run report of unreferenced uploads
+	admin.Post("/uploads/gc", adminHandler.CollectOrphanedUploads) // Delete unreferenced uploads now
+	admin.Get("/migrations/images", adminHandler.GetImageMigration)    // Base64 image migration progress and failures
+	admin.Post("/migrations/images", adminHandler.StartImageMigration) // Start or resume the base64 image migration
+	admin.Get("/moderation/images", adminHandler.GetImageFlags)                                     // Near-duplicate image queue
+	admin.Patch("/moderation/images/:id", middleware.ValidateJSON(), adminHandler.ReviewImageFlag) // Dismiss or ban a flagged image
+	admin.Put("/users/:id/trusted", middleware.ValidateJSON(), adminHandler.SetTrustedSeller)      // Mark or unmark a trusted seller
+	admin.Get("/users/:id/history", adminHandler.GetProfileHistory)                                // SRN, semester and alumni changes
 }
 
 func SetupProfileRoutes(api fiber.Router) {
 	userService := services.NewUserService()
 	userHandler := handlers.NewUserHandler(userService)
+	reviewHandler := handlers.NewReviewHandler(services.NewReviewService())
 
 	profile := api.Group("/profile")
 	
 	// Public endpoints
-	profile.Get("/:id", userHandler.GetProfile)  // Get user profile (public)
+	profile.Get("/:id", middleware.OptionalJWTAuth(), userHandler.GetProfile) // Public profile; full record for the user and admins
+	profile.Get("/:id/avatar", userHandler.GetAvatar)                         // Avatar image, or generated initials if none is set
+	profile.Get("/:id/reviews", reviewHandler.GetUserReviews)                 // Reviews the user has received
 	
 	// Protected route requiring authentication
 	profile.Put("/:id", middleware.JWTAuth(), middleware.ValidateJSON(), userHandler.UpdateProfile)  // Update user profile
 }
 
+func SetupHandleRoutes(api fiber.Router) {
+	handleHandler := handlers.NewHandleHandler(services.NewUserService())
+
+	handles := api.Group("/handles")
+	
+	// Public endpoints
+	handles.Get("/:handle", middleware.OptionalJWTAuth(), handleHandler.CheckHandle)                // Is a handle available to claim
+	handles.Get("/:handle/profile", middleware.OptionalJWTAuth(), handleHandler.GetProfileByHandle) // Public profile by handle; old handles redirect
+}
+
 func SetupItemRoutes(api fiber.Router) {
 	itemService := services.NewItemService()
 	itemHandler := handlers.NewItemHandler(itemService)
 	imageHandler := handlers.NewImageHandler()
+	transactionHandler := handlers.NewTransactionHandler(services.NewTransactionService())
 
 	items := api.Group("/items")
 	
 	// Public endpoints
-	items.Get("/", itemHandler.GetItems)      // Get all items with filters and pagination
+	items.Get("/", middleware.OptionalJWTAuth(), itemHandler.GetItems) // Get all items with filters and pagination
 	items.Get("/:id", itemHandler.GetItem)   // Get single item by ID
 	items.Get("/:id/image/:index", itemHandler.GetItemImage) // Get item image
 	items.Get("/seller/:sellerId", itemHandler.GetItemsBySeller) // Get items by seller ID
@@ -68,12 +174,40 @@ func SetupItemRoutes(api fiber.Router) {
 	items.Post("/", middleware.JWTAuth(), middleware.ValidateJSON(), itemHandler.CreateItem)           // Create new item
 	items.Put("/:id", middleware.JWTAuth(), middleware.ValidateJSON(), itemHandler.UpdateItem)        // Update item
 	items.Delete("/:id", middleware.JWTAuth(), itemHandler.DeleteItem)                                // Delete item
+	items.Put("/:id/images/order", middleware.JWTAuth(), middleware.ValidateJSON(), itemHandler.ReorderItemImages) // Reorder images
+	items.Post("/:id/images/:imageId/cover", middleware.JWTAuth(), itemHandler.SetItemCoverImage)                // Make an image the cover
+	items.Patch("/:id/images/:imageId", middleware.JWTAuth(), middleware.ValidateJSON(), itemHandler.UpdateItemImage) // Replace an image or edit its caption
+	items.Delete("/:id/images/:imageId", middleware.JWTAuth(), itemHandler.RemoveItemImage)                      // Remove an image
+	items.Post("/:id/sold", middleware.JWTAuth(), middleware.ValidateJSON(), transactionHandler.MarkItemSold)     // Mark sold to a buyer, pending their confirmation
+	items.Get("/:id/buyers", middleware.JWTAuth(), transactionHandler.GetBuyerCandidates)                        // People I can mark the item sold to
 	
 	// Image management routes
-	items.Post("/upload-images", middleware.JWTAuth(), imageHandler.UploadImage)                      // Upload images to Supabase Storage
+	items.Post("/upload-images", middleware.JWTAuth(), imageHandler.UploadImage)                      // Upload images to object storage
 	items.Post("/convert-images", middleware.JWTAuth(), middleware.ValidateJSON(), imageHandler.ConvertBase64ToStorage) // Convert base64 to storage URLs
 }
 
+func SetupReviewRoutes(api fiber.Router) {
+	reviewHandler := handlers.NewReviewHandler(services.NewReviewService())
+
+	// Protected review routes requiring authentication
+	reviews := api.Group("/reviews", middleware.JWTAuth())
+	
+	reviews.Post("/", middleware.ValidateJSON(), reviewHandler.CreateReview)           // Review the other party of a sale
+	reviews.Post("/:id/reply", middleware.ValidateJSON(), reviewHandler.ReplyToReview) // Seller's public reply to a review
+}
+
+func SetupTransactionRoutes(api fiber.Router) {
+	transactionHandler := handlers.NewTransactionHandler(services.NewTransactionService())
+
+	// Protected transaction routes; only the buyer and seller can see or change a transaction
+	transactions := api.Group("/transactions", middleware.JWTAuth())
+	
+	transactions.Get("/:id", transactionHandler.GetTransaction)                                         // Get a transaction
+	transactions.Post("/:id/confirm", transactionHandler.ConfirmTransaction)                            // Buyer confirms the purchase
+	transactions.Post("/:id/cancel", transactionHandler.CancelTransaction)                              // Call off a pending sale
+	transactions.Post("/:id/dispute", middleware.ValidateJSON(), transactionHandler.DisputeTransaction) // Report a problem with a sale
+}
+
 func SetupMessageRoutes(api fiber.Router) {
 	messageService := services.NewMessageService()
 	messageHandler := handlers.NewMessageHandler(messageService)
@@ -84,8 +218,13 @@ func SetupMessageRoutes(api fiber.Router) {
 	messages.Post("/", middleware.JWTAuth(), middleware.ValidateJSON(), messageHandler.SendMessage)            // Send a new message
 	messages.Get("/", middleware.JWTAuth(), messageHandler.GetMessages)                                       // Get messages between users for an item
 	messages.Put("/read", middleware.JWTAuth(), middleware.ValidateJSON(), messageHandler.MarkAsRead)         // Mark messages as read
+	messages.Get("/search", middleware.JWTAuth(), messageHandler.SearchMessages)                              // Search my message history
+	messages.Patch("/:id", middleware.JWTAuth(), middleware.ValidateJSON(), messageHandler.EditMessage)       // Edit a message within the edit window
+	messages.Get("/:id/edits", middleware.JWTAuth(), messageHandler.GetMessageEdits)                          // Get a message's edit history
+	messages.Delete("/:id", middleware.JWTAuth(), messageHandler.DeleteMessage)                               // Unsend (?for=everyone) or delete for me
 
 	// Get active chats endpoint (protected)
 	chats := api.Group("/active-chats")
 	chats.Get("/", middleware.JWTAuth(), messageHandler.GetActiveChats)
+	chats.Patch("/:otherUserId", middleware.JWTAuth(), middleware.ValidateJSON(), messageHandler.UpdateConversation) // Archive or mute a conversation
 }
\ No newline at end of file
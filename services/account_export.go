@@ -0,0 +1,172 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"time"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+	"pesxchange-backend/storage"
+	"pesxchange-backend/utils"
+)
+
+const exportPageSize = 1000 // Rows fetched per request when exporting
+
+// ExportData gathers everything stored about a user: their profile, listings, both sides of
+// their conversations, reviews, sales, follows, blocks, notifications and profile history
+func (s *AccountService) ExportData(ctx context.Context, userID string) (*models.AccountExport, error) {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.AccountExport{
+		ExportedAt: time.Now(),
+		Profile:    user,
+	}
+
+	either := func(a, b string) string {
+		return fmt.Sprintf("%s.eq.%s,%s.eq.%s", a, userID, b, userID)
+	}
+
+	exports := []struct {
+		table  string
+		filter string
+		out    interface{}
+	}{
+		{"items", "seller_id.eq." + userID, &export.Items},
+		{"messages", either("sender_id", "receiver_id"), &export.Messages},
+		{"reviews", "reviewer_id.eq." + userID, &export.ReviewsGiven},
+		{"reviews", "reviewee_id.eq." + userID, &export.ReviewsReceived},
+		{"transactions", either("buyer_id", "seller_id"), &export.Transactions},
+		{"user_follows", "follower_id.eq." + userID, &export.Following},
+		{"user_follows", "followee_id.eq." + userID, &export.Followers},
+		{"user_blocks", "blocker_id.eq." + userID, &export.Blocks},
+		{"notifications", "user_id.eq." + userID, &export.Notifications},
+		{"user_profile_history", "user_id.eq." + userID, &export.ProfileHistory},
+	}
+
+	for _, e := range exports {
+		if err := exportRows(ctx, e.table, e.filter, e.out); err != nil {
+			return nil, err
+		}
+	}
+
+	return export, nil
+}
+
+// WriteExportArchive writes a user's export as a ZIP: data.json plus the full-size images of
+// their listings and their avatar. Images that can't be fetched are skipped.
+func (s *AccountService) WriteExportArchive(ctx context.Context, userID string, w io.Writer) error {
+	export, err := s.ExportData(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode export: %w", err)
+	}
+	if err := writeArchiveFile(archive, "data.json", data); err != nil {
+		return err
+	}
+
+	itemStore := storage.GetStore(ItemImagesBucket)
+	for _, item := range export.Items {
+		for i, url := range item.Images {
+			name := fmt.Sprintf("images/items/%s/%d", item.ID, i+1)
+			if err := addStoredImage(ctx, archive, itemStore, utils.VariantURL(url, utils.VariantFull), name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if export.Profile.AvatarURL != "" {
+		avatarURL := AvatarVariantURL(export.Profile.AvatarURL, utils.AvatarLarge)
+		if err := addStoredImage(ctx, archive, storage.GetStore(AvatarsBucket), avatarURL, "images/avatar"); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	return nil
+}
+
+// addStoredImage copies the object behind url into the archive as name plus the object's
+// extension. URLs outside the store are skipped.
+func addStoredImage(ctx context.Context, archive *zip.Writer, store storage.ObjectStore, url, name string) error {
+	key, ok := storage.KeyFromURL(store, url)
+	if !ok {
+		return nil
+	}
+
+	object, err := store.Get(ctx, key)
+	if err != nil {
+		log.Printf("Skipping %s in export: %v", key, err)
+		return nil
+	}
+
+	return writeArchiveFile(archive, name+path.Ext(key), object.Data)
+}
+
+func writeArchiveFile(archive *zip.Writer, name string, data []byte) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+// exportRows pages through every row of table matching filter (a PostgREST "or" filter),
+// oldest first, decoding them into out, a pointer to a slice
+func exportRows(ctx context.Context, table, filter string, out interface{}) error {
+	client := database.GetClient()
+
+	rows := []json.RawMessage{}
+	for offset := 0; ; offset += exportPageSize {
+		var page []json.RawMessage
+		data, _, err := client.From(table).
+			Select("*", "exact", false).
+			Or(filter, "").
+			Order("created_at", &postgrestAscending).
+			Range(offset, offset+exportPageSize-1, "").
+			Execute()
+
+		if err != nil {
+			return fmt.Errorf("failed to get %s: %w", table, err)
+		}
+
+		if err := json.Unmarshal(data, &page); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", table, err)
+		}
+
+		rows = append(rows, page...)
+		if len(page) < exportPageSize {
+			break
+		}
+	}
+
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", table, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", table, err)
+	}
+
+	return nil
+}
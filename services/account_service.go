@@ -0,0 +1,459 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"pesxchange-backend/config"
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+	"pesxchange-backend/storage"
+	"pesxchange-backend/utils"
+)
+
+const (
+	DeletedUserName         = "Deleted user" // Shown in place of an anonymised user's name
+	accountDeletionPageSize = 200            // Rows fetched per request while purging an account
+)
+
+type AccountService struct {
+	userService        *UserService
+	transactionService *TransactionService
+}
+
+func NewAccountService() *AccountService {
+	return &AccountService{
+		userService:        NewUserService(),
+		transactionService: NewTransactionService(),
+	}
+}
+
+// RequestDeletion schedules a user's account for deletion once the grace period has passed.
+// Requesting again keeps the original schedule.
+func (s *AccountService) RequestDeletion(ctx context.Context, userID string) (*models.AccountDeletionStatus, error) {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletionScheduledFor != nil {
+		return deletionStatus(user), nil
+	}
+
+	now := time.Now()
+	scheduledFor := now.AddDate(0, 0, config.Load().AccountDeletionGraceDays)
+
+	updated, err := s.setDeletionSchedule(ctx, userID, &now, &scheduledFor)
+	if err != nil {
+		return nil, err
+	}
+
+	return deletionStatus(updated), nil
+}
+
+// CancelDeletion withdraws a pending deletion request
+func (s *AccountService) CancelDeletion(ctx context.Context, userID string) (*models.AccountDeletionStatus, error) {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletionScheduledFor == nil {
+		return nil, fmt.Errorf("deletion not scheduled")
+	}
+
+	updated, err := s.setDeletionSchedule(ctx, userID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return deletionStatus(updated), nil
+}
+
+// GetDeletionStatus reports whether the user's account is scheduled for deletion
+func (s *AccountService) GetDeletionStatus(ctx context.Context, userID string) (*models.AccountDeletionStatus, error) {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return deletionStatus(user), nil
+}
+
+// PurgeDueAccounts deletes every account whose grace period has ended, returning how many
+// were deleted. A failed account is logged and retried on the next run.
+func (s *AccountService) PurgeDueAccounts(ctx context.Context) (int, error) {
+	var users []models.User
+	data, _, err := database.GetClient().From("user_profiles").
+		Select("id", "exact", false).
+		Lte("deletion_scheduled_for", time.Now().UTC().Format(time.RFC3339Nano)).
+		Is("deleted_at", "null").
+		Limit(accountDeletionPageSize, "").
+		Execute()
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get accounts due for deletion: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &users); err != nil {
+		return 0, fmt.Errorf("failed to parse accounts: %w", err)
+	}
+
+	purged := 0
+	for _, user := range users {
+		if err := s.DeleteAccount(ctx, user.ID); err != nil {
+			log.Printf("Failed to delete account %s: %v", user.ID, err)
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// DeleteAccount removes a user's personal data while keeping the records other users rely on:
+//   - pending sales are cancelled; unsold listings are deleted and sold ones kept without images
+//   - the user's images and avatar are deleted from storage
+//   - messages they sent are unsent, so the other side sees where they were in the thread
+//   - follows, blocks, notifications, old handles and profile history are removed
+//   - the user_profiles row is anonymised rather than deleted, so reviews and transactions
+//     still point at a "Deleted user"
+func (s *AccountService) DeleteAccount(ctx context.Context, userID string) error {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.DeletedAt != nil {
+		return nil
+	}
+
+	if err := s.cancelPendingTransactions(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.removeListings(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.removeUploads(ctx, userID); err != nil {
+		return err
+	}
+	deleteAvatar(ctx, user.AvatarURL)
+
+	if err := s.redactMessages(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.removeRelationships(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.anonymiseProfile(ctx, userID)
+}
+
+// cancelPendingTransactions calls off sales the buyer hasn't confirmed, relisting the
+// counterpart's items
+func (s *AccountService) cancelPendingTransactions(ctx context.Context, userID string) error {
+	var transactions []models.Transaction
+	err := exportRows(ctx, "transactions", fmt.Sprintf("buyer_id.eq.%s,seller_id.eq.%s", userID, userID), &transactions)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range transactions {
+		if t.Status != TransactionPending {
+			continue
+		}
+		if _, err := s.transactionService.CancelTransaction(ctx, t.ID, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeListings deletes the user's unsold items and strips the images from sold ones,
+// which stay behind for the buyer's purchase history and reviews
+func (s *AccountService) removeListings(ctx context.Context, userID string) error {
+	client := database.GetClient()
+	store := storage.GetStore(ItemImagesBucket)
+
+	var items []models.Item
+	if err := exportRows(ctx, "items", "seller_id.eq."+userID, &items); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var keys []string
+		for _, url := range item.Images {
+			for _, variant := range utils.ImageVariants {
+				if key, ok := storage.KeyFromURL(store, utils.VariantURL(url, variant.Name)); ok {
+					keys = append(keys, key)
+				}
+			}
+		}
+
+		if item.BuyerID != nil {
+			_, _, err := client.From("items").
+				Update(map[string]interface{}{
+					"images":        []string{},
+					"image_details": []models.ItemImage{},
+					"is_available":  false,
+					"updated_at":    time.Now(),
+				}, "", "").
+				Eq("id", item.ID).
+				Execute()
+
+			if err != nil {
+				return fmt.Errorf("failed to clear item images: %w", err)
+			}
+		} else {
+			// Conversations about the item carry on as direct messages
+			_, _, err := client.From("messages").
+				Update(map[string]interface{}{"item_id": nil}, "", "").
+				Eq("item_id", item.ID).
+				Execute()
+
+			if err != nil {
+				return fmt.Errorf("failed to detach messages from item: %w", err)
+			}
+
+			_, _, err = client.From("items").
+				Delete("", "").
+				Eq("id", item.ID).
+				Execute()
+
+			if err != nil {
+				return fmt.Errorf("failed to delete item: %w", err)
+			}
+		}
+
+		if len(keys) > 0 {
+			if err := store.Delete(ctx, keys...); err != nil {
+				log.Printf("Failed to delete images of item %s: %v", item.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeUploads deletes the objects behind every upload the user made, including ones never
+// attached to an item, and their upload records
+func (s *AccountService) removeUploads(ctx context.Context, userID string) error {
+	store := storage.GetStore(ItemImagesBucket)
+
+	var uploads []models.Upload
+	if err := exportRows(ctx, "uploads", "user_id.eq."+userID, &uploads); err != nil {
+		return err
+	}
+
+	for _, upload := range uploads {
+		var keys []string
+		if upload.ObjectKey != "" {
+			keys = append(keys, upload.ObjectKey)
+		}
+		if upload.URL != nil {
+			for _, variant := range utils.ImageVariants {
+				if key, ok := storage.KeyFromURL(store, utils.VariantURL(*upload.URL, variant.Name)); ok {
+					keys = append(keys, key)
+				}
+			}
+		}
+
+		if len(keys) > 0 {
+			if err := store.Delete(ctx, keys...); err != nil {
+				log.Printf("Failed to delete objects of upload %s: %v", upload.ID, err)
+			}
+		}
+	}
+
+	_, _, err := database.GetClient().From("uploads").
+		Delete("", "").
+		Eq("user_id", userID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to delete upload records: %w", err)
+	}
+
+	return nil
+}
+
+// redactMessages unsends every message the user sent: the text, edit history and screening
+// hits are removed but the rows stay, so the recipient's threads remain in order. Messages
+// the user received are the other side's and are left alone.
+func (s *AccountService) redactMessages(ctx context.Context, userID string) error {
+	client := database.GetClient()
+
+	for {
+		var messages []models.Message
+		data, _, err := client.From("messages").
+			Select("id", "exact", false).
+			Eq("sender_id", userID).
+			Is("unsent_at", "null").
+			Limit(accountDeletionPageSize, "").
+			Execute()
+
+		if err != nil {
+			return fmt.Errorf("failed to get messages: %w", err)
+		}
+
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("failed to parse messages: %w", err)
+		}
+
+		if len(messages) == 0 {
+			break
+		}
+
+		ids := make([]string, 0, len(messages))
+		for _, m := range messages {
+			ids = append(ids, m.ID)
+		}
+
+		_, _, err = client.From("message_edits").
+			Delete("", "").
+			In("message_id", ids).
+			Execute()
+
+		if err != nil {
+			return fmt.Errorf("failed to remove message edits: %w", err)
+		}
+
+		_, _, err = client.From("messages").
+			Update(map[string]interface{}{
+				"message":   "",
+				"unsent_at": time.Now().Format(time.RFC3339),
+			}, "", "").
+			In("id", ids).
+			Execute()
+
+		if err != nil {
+			return fmt.Errorf("failed to redact messages: %w", err)
+		}
+
+		if len(messages) < accountDeletionPageSize {
+			break
+		}
+	}
+
+	_, _, err := client.From("message_screening_hits").
+		Delete("", "").
+		Eq("sender_id", userID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to remove screening hits: %w", err)
+	}
+
+	return nil
+}
+
+// removeRelationships deletes the user's follows, blocks, notifications, conversation
+// settings, old handle redirects, vacation auto-reply records and profile history
+func (s *AccountService) removeRelationships(ctx context.Context, userID string) error {
+	client := database.GetClient()
+
+	deletes := []struct {
+		table  string
+		filter string
+	}{
+		{"user_follows", fmt.Sprintf("follower_id.eq.%s,followee_id.eq.%s", userID, userID)},
+		{"user_blocks", fmt.Sprintf("blocker_id.eq.%s,blocked_id.eq.%s", userID, userID)},
+		{"notifications", fmt.Sprintf("user_id.eq.%s,actor_id.eq.%s", userID, userID)},
+		{"conversation_settings", "user_id.eq." + userID},
+		{"handle_redirects", "user_id.eq." + userID},
+		{"vacation_auto_replies", fmt.Sprintf("user_id.eq.%s,other_user_id.eq.%s", userID, userID)},
+		{"user_profile_history", "user_id.eq." + userID},
+	}
+
+	for _, d := range deletes {
+		_, _, err := client.From(d.table).
+			Delete("", "").
+			Or(d.filter, "").
+			Execute()
+
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", d.table, err)
+		}
+	}
+
+	return nil
+}
+
+// anonymiseProfile clears everything identifying from the user's profile. The SRN is replaced
+// so logging in again with it creates a fresh account.
+func (s *AccountService) anonymiseProfile(ctx context.Context, userID string) error {
+	now := time.Now()
+
+	_, _, err := database.GetClient().From("user_profiles").
+		Update(map[string]interface{}{
+			"srn":                    "deleted-" + userID,
+			"prn":                    "",
+			"name":                   DeletedUserName,
+			"nickname":               "",
+			"email":                  "",
+			"phone":                  "",
+			"bio":                    "",
+			"avatar_url":             "",
+			"program":                "",
+			"branch":                 "",
+			"semester":               "",
+			"section":                "",
+			"campus":                 "",
+			"campus_code":            nil,
+			"location":               "",
+			"verified":               false,
+			"trusted_seller":         false,
+			"profile_visibility":     nil,
+			"handle":                 nil,
+			"handle_changed_at":      nil,
+			"vacation_started_at":    nil,
+			"vacation_until":         nil,
+			"vacation_message":       "",
+			"alumni_since":           nil,
+			"deletion_scheduled_for": nil,
+			"deleted_at":             now,
+			"updated_at":             now,
+		}, "", "").
+		Eq("id", userID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to anonymise profile: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AccountService) setDeletionSchedule(ctx context.Context, userID string, requestedAt, scheduledFor *time.Time) (*models.User, error) {
+	var updatedUsers []models.User
+	data, _, err := database.GetClient().From("user_profiles").
+		Update(map[string]interface{}{
+			"deletion_requested_at":  requestedAt,
+			"deletion_scheduled_for": scheduledFor,
+		}, "", "").
+		Eq("id", userID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update deletion schedule: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &updatedUsers); err != nil {
+		return nil, fmt.Errorf("failed to parse updated user: %w", err)
+	}
+
+	if len(updatedUsers) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	return &updatedUsers[0], nil
+}
+
+func deletionStatus(user *models.User) *models.AccountDeletionStatus {
+	return &models.AccountDeletionStatus{
+		Scheduled:    user.DeletionScheduledFor != nil,
+		RequestedAt:  user.DeletionRequestedAt,
+		ScheduledFor: user.DeletionScheduledFor,
+	}
+}
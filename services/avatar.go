@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"pesxchange-backend/config"
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+	"pesxchange-backend/storage"
+	"pesxchange-backend/utils"
+
+	"github.com/google/uuid"
+)
+
+const AvatarsBucket = "avatars" // Storage bucket for profile pictures, kept apart from item images
+
+// SetAvatar crops an uploaded image to a square, stores its variants and makes it the user's
+// avatar, deleting the previous one. The returned user's AvatarURL is the large variant.
+func (s *UserService) SetAvatar(ctx context.Context, userID string, data []byte) (*models.User, error) {
+	processed, err := utils.ProcessAvatar(data, MaxImageDimension)
+	if err != nil {
+		return nil, fmt.Errorf("processing failed: %w", err)
+	}
+
+	if config.Load().BlockBannedImages {
+		banned, err := isBannedHash(ctx, processed.PHash)
+		if err != nil {
+			return nil, err
+		}
+		if banned {
+			return nil, fmt.Errorf("image is banned")
+		}
+	}
+
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Objects live under the user's ID so UpdateUserProfile can tell whose avatar a URL is
+	store := storage.GetStore(AvatarsBucket)
+	baseName := fmt.Sprintf("%s/%s", userID, uuid.New().String())
+
+	var keys []string
+	for _, variant := range utils.AvatarVariants {
+		key := utils.VariantFilename(baseName, variant.Name)
+		if err := store.Put(ctx, key, processed.Variants[variant.Name], utils.ProcessedImageContentType); err != nil {
+			deleteAvatarObjects(ctx, keys...)
+			return nil, fmt.Errorf("upload failed: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	updated, err := s.setAvatarURL(ctx, userID, store.PublicURL(utils.VariantFilename(baseName, utils.AvatarLarge)))
+	if err != nil {
+		deleteAvatarObjects(ctx, keys...)
+		return nil, err
+	}
+
+	deleteAvatar(ctx, user.AvatarURL)
+	return updated, nil
+}
+
+// RemoveAvatar clears the user's avatar, deleting it from storage if we host it
+func (s *UserService) RemoveAvatar(ctx context.Context, userID string) (*models.User, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.setAvatarURL(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	deleteAvatar(ctx, user.AvatarURL)
+	return updated, nil
+}
+
+// AvatarVariantURL returns the URL of an avatar in the given size (see utils.AvatarVariants).
+// Avatars that weren't uploaded through SetAvatar are returned unchanged.
+func AvatarVariantURL(avatarURL, size string) string {
+	for _, v := range utils.AvatarVariants {
+		suffix := "_" + v.Name + utils.ProcessedImageExtension
+		if strings.HasSuffix(avatarURL, suffix) {
+			return strings.TrimSuffix(avatarURL, suffix) + "_" + size + utils.ProcessedImageExtension
+		}
+	}
+	return avatarURL
+}
+
+func (s *UserService) setAvatarURL(ctx context.Context, userID, avatarURL string) (*models.User, error) {
+	var updatedUsers []models.User
+	data, _, err := database.GetClient().From("user_profiles").
+		Update(map[string]interface{}{
+			"avatar_url": avatarURL,
+			"updated_at": time.Now(),
+		}, "", "").
+		Eq("id", userID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update avatar: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &updatedUsers); err != nil {
+		return nil, fmt.Errorf("failed to parse updated user: %w", err)
+	}
+
+	if len(updatedUsers) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	return &updatedUsers[0], nil
+}
+
+// deleteAvatar removes every variant of an avatar stored in the avatars bucket. Other URLs
+// (such as item images set as avatars before uploads existed) are left alone.
+func deleteAvatar(ctx context.Context, avatarURL string) {
+	if avatarURL == "" {
+		return
+	}
+
+	store := storage.GetStore(AvatarsBucket)
+	var keys []string
+	for _, variant := range utils.AvatarVariants {
+		if key, ok := storage.KeyFromURL(store, AvatarVariantURL(avatarURL, variant.Name)); ok {
+			keys = append(keys, key)
+		}
+	}
+	deleteAvatarObjects(ctx, keys...)
+}
+
+func deleteAvatarObjects(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	if err := storage.GetStore(AvatarsBucket).Delete(ctx, keys...); err != nil {
+		log.Printf("Failed to delete avatar objects %v: %v", keys, err)
+	}
+}
+
+// isOwnAvatarURL reports whether url is an avatar uploaded by userID
+func isOwnAvatarURL(userID, url string) bool {
+	key, ok := storage.KeyFromURL(storage.GetStore(AvatarsBucket), url)
+	return ok && strings.HasPrefix(key, userID+"/")
+}
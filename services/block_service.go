@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+
+	"github.com/google/uuid"
+)
+
+type BlockService struct{}
+
+func NewBlockService() *BlockService {
+	return &BlockService{}
+}
+
+// BlockUser records that blockerID has blocked blockedID
+func (s *BlockService) BlockUser(ctx context.Context, blockerID, blockedID string) (*models.UserBlock, error) {
+	client := database.GetClient()
+
+	if blockerID == blockedID {
+		return nil, fmt.Errorf("cannot block yourself")
+	}
+
+	// Check that the user being blocked exists
+	data, _, err := client.From("user_profiles").
+		Select("id", "exact", false).
+		Eq("id", blockedID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate user: %w", err)
+	}
+
+	var users []models.User
+	if err := json.Unmarshal(data, &users); err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	// Blocking twice is a no-op, return the existing block
+	existing, err := s.getBlock(ctx, blockerID, blockedID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	block := &models.UserBlock{
+		ID:        uuid.New().String(),
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+		CreatedAt: time.Now(),
+	}
+
+	_, _, err = client.From("user_blocks").
+		Insert(block, false, "", "", "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to block user: %w", err)
+	}
+
+	// Blocking ends any follow between the two users, in both directions
+	_, _, err = client.From("user_follows").
+		Delete("", "").
+		Or(fmt.Sprintf("and(follower_id.eq.%s,followee_id.eq.%s),and(follower_id.eq.%s,followee_id.eq.%s)", blockerID, blockedID, blockedID, blockerID), "").
+		Execute()
+
+	if err != nil {
+		log.Printf("Failed to remove follows between %s and %s: %v", blockerID, blockedID, err)
+	}
+
+	return block, nil
+}
+
+// UnblockUser removes a block created by blockerID
+func (s *BlockService) UnblockUser(ctx context.Context, blockerID, blockedID string) error {
+	client := database.GetClient()
+
+	existing, err := s.getBlock(ctx, blockerID, blockedID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("block not found")
+	}
+
+	_, _, err = client.From("user_blocks").
+		Delete("", "").
+		Eq("id", existing.ID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to unblock user: %w", err)
+	}
+
+	return nil
+}
+
+// GetBlockedUsers lists the users blockerID has blocked, newest first
+func (s *BlockService) GetBlockedUsers(ctx context.Context, blockerID string) ([]models.UserBlock, error) {
+	client := database.GetClient()
+
+	var blocks []models.UserBlock
+	data, _, err := client.From("user_blocks").
+		Select("*", "exact", false).
+		Eq("blocker_id", blockerID).
+		Order("created_at", nil).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocked users: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return nil, fmt.Errorf("failed to parse blocked users: %w", err)
+	}
+
+	if len(blocks) == 0 {
+		return []models.UserBlock{}, nil
+	}
+
+	// Attach a minimal profile for each blocked user so the client can render the list
+	ids := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		ids = append(ids, b.BlockedID)
+	}
+
+	var users []models.User
+	userData, _, err := client.From("user_profiles").
+		Select("id, nickname, name, avatar_url, handle, profile_visibility", "exact", false).
+		In("id", ids).
+		Execute()
+
+	if err == nil {
+		if err := json.Unmarshal(userData, &users); err == nil {
+			byID := make(map[string]*models.User, len(users))
+			for i := range users {
+				RedactUser(&users[i])
+				byID[users[i].ID] = &users[i]
+			}
+			for i := range blocks {
+				blocks[i].BlockedUser = byID[blocks[i].BlockedID]
+			}
+		}
+	}
+
+	return blocks, nil
+}
+
+// IsBlocked reports whether either user has blocked the other
+func (s *BlockService) IsBlocked(ctx context.Context, userID, otherUserID string) (bool, error) {
+	client := database.GetClient()
+
+	data, _, err := client.From("user_blocks").
+		Select("id", "exact", false).
+		Or(fmt.Sprintf("and(blocker_id.eq.%s,blocked_id.eq.%s),and(blocker_id.eq.%s,blocked_id.eq.%s)", userID, otherUserID, otherUserID, userID), "").
+		Limit(1, "").
+		Execute()
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check block: %w", err)
+	}
+
+	var blocks []models.UserBlock
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return false, fmt.Errorf("failed to parse block: %w", err)
+	}
+
+	return len(blocks) > 0, nil
+}
+
+// GetBlockedUserIDs returns the IDs of every user on the other side of a block involving userID,
+// regardless of who blocked whom
+func (s *BlockService) GetBlockedUserIDs(ctx context.Context, userID string) (map[string]bool, error) {
+	client := database.GetClient()
+
+	data, _, err := client.From("user_blocks").
+		Select("blocker_id,blocked_id", "exact", false).
+		Or(fmt.Sprintf("blocker_id.eq.%s,blocked_id.eq.%s", userID, userID), "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocks: %w", err)
+	}
+
+	var blocks []models.UserBlock
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return nil, fmt.Errorf("failed to parse blocks: %w", err)
+	}
+
+	ids := make(map[string]bool, len(blocks))
+	for _, b := range blocks {
+		if b.BlockerID == userID {
+			ids[b.BlockedID] = true
+		} else {
+			ids[b.BlockerID] = true
+		}
+	}
+
+	return ids, nil
+}
+
+// getBlock fetches a single block row, returning nil if none exists
+func (s *BlockService) getBlock(ctx context.Context, blockerID, blockedID string) (*models.UserBlock, error) {
+	client := database.GetClient()
+
+	var blocks []models.UserBlock
+	data, _, err := client.From("user_blocks").
+		Select("*", "exact", false).
+		Eq("blocker_id", blockerID).
+		Eq("blocked_id", blockedID).
+		Limit(1, "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return nil, fmt.Errorf("failed to parse block: %w", err)
+	}
+
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+
+	return &blocks[0], nil
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+)
+
+const followPageSize = 1000 // Rows fetched per request when listing follows
+
+type FollowService struct {
+	blockService        *BlockService
+	notificationService *NotificationService
+}
+
+func NewFollowService() *FollowService {
+	return &FollowService{
+		blockService:        NewBlockService(),
+		notificationService: NewNotificationService(),
+	}
+}
+
+// Follow makes followerID follow followeeID. Following twice is a no-op.
+func (s *FollowService) Follow(ctx context.Context, followerID, followeeID string) (*models.UserFollow, error) {
+	client := database.GetClient()
+
+	if followerID == followeeID {
+		return nil, fmt.Errorf("cannot follow yourself")
+	}
+
+	// Check that the user being followed exists
+	data, _, err := client.From("user_profiles").
+		Select("id", "exact", false).
+		Eq("id", followeeID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate user: %w", err)
+	}
+
+	var users []models.User
+	if err := json.Unmarshal(data, &users); err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	blocked, err := s.blockService.IsBlocked(ctx, followerID, followeeID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, fmt.Errorf("user blocked")
+	}
+
+	follow := &models.UserFollow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+		CreatedAt:  time.Now(),
+	}
+
+	_, _, err = client.From("user_follows").
+		Upsert(follow, "follower_id,followee_id", "", "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to follow user: %w", err)
+	}
+
+	return follow, nil
+}
+
+// Unfollow removes a follow; unfollowing someone you don't follow is a no-op
+func (s *FollowService) Unfollow(ctx context.Context, followerID, followeeID string) error {
+	_, _, err := database.GetClient().From("user_follows").
+		Delete("", "").
+		Eq("follower_id", followerID).
+		Eq("followee_id", followeeID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to unfollow user: %w", err)
+	}
+
+	return nil
+}
+
+// IsFollowing reports whether followerID follows followeeID
+func (s *FollowService) IsFollowing(ctx context.Context, followerID, followeeID string) (bool, error) {
+	var follows []models.UserFollow
+	data, _, err := database.GetClient().From("user_follows").
+		Select("follower_id", "exact", false).
+		Eq("follower_id", followerID).
+		Eq("followee_id", followeeID).
+		Execute()
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check follow: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &follows); err != nil {
+		return false, fmt.Errorf("failed to parse follow: %w", err)
+	}
+
+	return len(follows) > 0, nil
+}
+
+// GetFollowingIDs returns the IDs of the users userID follows
+func (s *FollowService) GetFollowingIDs(ctx context.Context, userID string) ([]string, error) {
+	return s.listFollows(ctx, "follower_id", userID, "followee_id")
+}
+
+// GetFollowerIDs returns the IDs of the users following userID
+func (s *FollowService) GetFollowerIDs(ctx context.Context, userID string) ([]string, error) {
+	return s.listFollows(ctx, "followee_id", userID, "follower_id")
+}
+
+// NotifyFollowers tells a seller's followers about a new listing. Followers who have
+// blocked the seller (or been blocked) are skipped.
+func (s *FollowService) NotifyFollowers(ctx context.Context, item *models.Item) error {
+	followerIDs, err := s.GetFollowerIDs(ctx, item.SellerID)
+	if err != nil || len(followerIDs) == 0 {
+		return err
+	}
+
+	blockedIDs, err := s.blockService.GetBlockedUserIDs(ctx, item.SellerID)
+	if err != nil {
+		return err
+	}
+
+	recipients := make([]string, 0, len(followerIDs))
+	for _, id := range followerIDs {
+		if !blockedIDs[id] {
+			recipients = append(recipients, id)
+		}
+	}
+
+	return s.notificationService.Notify(ctx, recipients, NotificationNewListing, &item.SellerID, &item.ID,
+		fmt.Sprintf("New listing: %s", item.Title))
+}
+
+// notifyFollowersAsync runs NotifyFollowers in the background so posting an item never waits on it
+func (s *FollowService) notifyFollowersAsync(item *models.Item) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		if err := s.NotifyFollowers(ctx, item); err != nil {
+			log.Printf("Failed to notify followers of item %s: %v", item.ID, err)
+		}
+	}()
+}
+
+// getFollowCounts counts a user's followers and the users they follow
+func getFollowCounts(ctx context.Context, userID string) (followers, following int, err error) {
+	client := database.GetClient()
+
+	_, count, err := client.From("user_follows").
+		Select("follower_id", "exact", false).
+		Eq("followee_id", userID).
+		Limit(1, "").
+		Execute()
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count followers: %w", err)
+	}
+	followers = int(count)
+
+	_, count, err = client.From("user_follows").
+		Select("followee_id", "exact", false).
+		Eq("follower_id", userID).
+		Limit(1, "").
+		Execute()
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count following: %w", err)
+	}
+
+	return followers, int(count), nil
+}
+
+// listFollows pages through user_follows rows where column equals userID, returning idColumn of each
+func (s *FollowService) listFollows(ctx context.Context, column, userID, idColumn string) ([]string, error) {
+	client := database.GetClient()
+
+	var ids []string
+	for offset := 0; ; offset += followPageSize {
+		var follows []models.UserFollow
+		data, _, err := client.From("user_follows").
+			Select(idColumn, "exact", false).
+			Eq(column, userID).
+			Range(offset, offset+followPageSize-1, "").
+			Execute()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get follows: %w", err)
+		}
+
+		if err := json.Unmarshal(data, &follows); err != nil {
+			return nil, fmt.Errorf("failed to parse follows: %w", err)
+		}
+
+		for _, f := range follows {
+			if idColumn == "follower_id" {
+				ids = append(ids, f.FollowerID)
+			} else {
+				ids = append(ids, f.FolloweeID)
+			}
+		}
+
+		if len(follows) < followPageSize {
+			return ids, nil
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"pesxchange-backend/config"
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+)
+
+const (
+	MinHandleLength = 3
+	MaxHandleLength = 20
+)
+
+// Reasons a handle can't be claimed, as reported by CheckHandle
+const (
+	HandleInvalid  = "invalid"
+	HandleReserved = "reserved"
+	HandleBlocked  = "blocked"
+	HandleTaken    = "taken"
+)
+
+// Lowercase letters, digits and underscores, starting with a letter and not ending with an underscore
+var handlePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*[a-z0-9]$`)
+
+// Digits commonly substituted for letters to sneak words past the blocklist
+var handleLeetReplacer = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "_", "")
+
+// Used when the rules file can't be loaded, so the obvious names are never up for grabs
+var defaultReservedHandles = []string{
+	"admin", "administrator", "root", "system", "support", "help", "moderator", "mod",
+	"staff", "official", "security", "pesxchange", "pes", "pesu", "api", "me",
+}
+
+// HandleCooldownError is returned when a user changes their handle again too soon
+type HandleCooldownError struct {
+	NextChangeAt time.Time
+}
+
+func (e *HandleCooldownError) Error() string {
+	return fmt.Sprintf("handle change on cooldown until %s", e.NextChangeAt.Format(time.RFC3339))
+}
+
+// handleRules are the reserved and blocked words from HANDLE_RULES_FILE
+type handleRules struct {
+	reserved map[string]bool // Matched against the whole handle
+	blocked  []string        // Matched anywhere in the handle
+}
+
+var (
+	defaultHandleRules     *handleRules
+	defaultHandleRulesOnce sync.Once
+)
+
+// getHandleRules returns the process-wide handle rules
+func getHandleRules() *handleRules {
+	defaultHandleRulesOnce.Do(func() {
+		cfg := config.Load()
+		rules, err := loadHandleRules(cfg.HandleRulesFile)
+		if err != nil {
+			log.Printf("Warning: using built-in handle rules, failed to load %s: %v", cfg.HandleRulesFile, err)
+			rules = &handleRules{reserved: make(map[string]bool)}
+			for _, word := range defaultReservedHandles {
+				rules.reserved[word] = true
+			}
+		}
+		defaultHandleRules = rules
+	})
+	return defaultHandleRules
+}
+
+func loadHandleRules(path string) (*handleRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Reserved []string `json:"reserved"`
+		Blocked  []string `json:"blocked"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid rules file: %w", err)
+	}
+
+	rules := &handleRules{reserved: make(map[string]bool, len(file.Reserved))}
+	for _, word := range file.Reserved {
+		rules.reserved[strings.ToLower(word)] = true
+	}
+	for _, word := range file.Blocked {
+		rules.blocked = append(rules.blocked, strings.ToLower(word))
+	}
+	return rules, nil
+}
+
+// check returns why handle can't be used, or "" if the rules allow it
+func (r *handleRules) check(handle string) string {
+	normalized := handleLeetReplacer.Replace(handle)
+	if r.reserved[handle] || r.reserved[strings.ReplaceAll(handle, "_", "")] {
+		return HandleReserved
+	}
+	for _, word := range r.blocked {
+		if strings.Contains(normalized, word) {
+			return HandleBlocked
+		}
+	}
+	return ""
+}
+
+// NormalizeHandle returns the canonical form of a handle: trimmed, without a leading @, lowercased
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
+
+// validateHandle checks a normalized handle against the format and word rules
+func validateHandle(handle string) string {
+	if len(handle) < MinHandleLength || len(handle) > MaxHandleLength || !handlePattern.MatchString(handle) {
+		return HandleInvalid
+	}
+	return getHandleRules().check(handle)
+}
+
+// CheckHandle reports whether a handle can be claimed. viewerID may be empty; a user's own
+// current or recently released handle is available to them.
+func (s *UserService) CheckHandle(ctx context.Context, handle, viewerID string) (*models.HandleAvailability, error) {
+	handle = NormalizeHandle(handle)
+	result := &models.HandleAvailability{Handle: handle}
+
+	if reason := validateHandle(handle); reason != "" {
+		result.Reason = reason
+		return result, nil
+	}
+
+	ownerID, err := s.handleOwner(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if ownerID != "" && ownerID != viewerID {
+		result.Reason = HandleTaken
+		return result, nil
+	}
+
+	result.Available = true
+	return result, nil
+}
+
+// SetHandle claims or changes a user's handle. Changes are limited to one per cooldown period
+// (a first claim is exempt); the old handle keeps redirecting to the user, and can't be taken by
+// anyone else, for the redirect period.
+func (s *UserService) SetHandle(ctx context.Context, userID, handle string) (*models.User, error) {
+	cfg := config.Load()
+	handle = NormalizeHandle(handle)
+
+	switch validateHandle(handle) {
+	case HandleInvalid:
+		return nil, fmt.Errorf("invalid handle")
+	case HandleReserved:
+		return nil, fmt.Errorf("handle reserved")
+	case HandleBlocked:
+		return nil, fmt.Errorf("handle not allowed")
+	}
+
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldHandle := ""
+	if user.Handle != nil {
+		oldHandle = *user.Handle
+	}
+	if oldHandle == handle {
+		return user, nil
+	}
+
+	now := time.Now()
+	if oldHandle != "" && user.HandleChangedAt != nil {
+		next := user.HandleChangedAt.AddDate(0, 0, cfg.HandleChangeCooldownDays)
+		if now.Before(next) {
+			return nil, &HandleCooldownError{NextChangeAt: next}
+		}
+	}
+
+	ownerID, err := s.handleOwner(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if ownerID != "" && ownerID != userID {
+		return nil, fmt.Errorf("handle taken")
+	}
+
+	client := database.GetClient()
+
+	// The unique index settles races between two users claiming the same handle
+	var updatedUsers []models.User
+	data, _, err := client.From("user_profiles").
+		Update(map[string]interface{}{
+			"handle":            handle,
+			"handle_changed_at": now,
+			"updated_at":        now,
+		}, "", "").
+		Eq("id", userID).
+		Execute()
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("handle taken")
+		}
+		return nil, fmt.Errorf("failed to update handle: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &updatedUsers); err != nil {
+		return nil, fmt.Errorf("failed to parse updated user: %w", err)
+	}
+
+	if len(updatedUsers) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	// Reclaiming a released handle ends its redirect
+	_, _, err = client.From("handle_redirects").
+		Delete("", "").
+		Eq("handle", handle).
+		Execute()
+
+	if err != nil {
+		log.Printf("Failed to clear redirect for handle %s: %v", handle, err)
+	}
+
+	if oldHandle != "" && cfg.HandleRedirectDays > 0 {
+		redirect := models.HandleRedirect{
+			Handle:    oldHandle,
+			UserID:    userID,
+			ExpiresAt: now.AddDate(0, 0, cfg.HandleRedirectDays),
+			CreatedAt: now,
+		}
+		_, _, err = client.From("handle_redirects").
+			Upsert(redirect, "handle", "", "").
+			Execute()
+
+		if err != nil {
+			log.Printf("Failed to record redirect for handle %s: %v", oldHandle, err)
+		}
+	}
+
+	return &updatedUsers[0], nil
+}
+
+// GetUserByHandle looks up a user by handle. If handle is one the user recently changed away
+// from, the user is returned along with their current handle so callers can redirect.
+func (s *UserService) GetUserByHandle(ctx context.Context, handle string) (*models.User, string, error) {
+	handle = NormalizeHandle(handle)
+	client := database.GetClient()
+
+	var users []models.User
+	data, _, err := client.From("user_profiles").
+		Select("*", "exact", false).
+		Eq("handle", handle).
+		Execute()
+
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, "", fmt.Errorf("failed to parse user: %w", err)
+	}
+
+	if len(users) > 0 {
+		return &users[0], "", nil
+	}
+
+	redirect, err := s.getHandleRedirect(ctx, handle)
+	if err != nil {
+		return nil, "", err
+	}
+	if redirect == nil {
+		return nil, "", fmt.Errorf("user not found")
+	}
+
+	user, err := s.GetUserByID(ctx, redirect.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user.Handle == nil {
+		return user, "", nil
+	}
+	return user, *user.Handle, nil
+}
+
+// handleOwner returns the ID of the user holding handle, currently or through an unexpired
+// redirect, or "" if it's free
+func (s *UserService) handleOwner(ctx context.Context, handle string) (string, error) {
+	var users []models.User
+	data, _, err := database.GetClient().From("user_profiles").
+		Select("id", "exact", false).
+		Eq("handle", handle).
+		Execute()
+
+	if err != nil {
+		return "", fmt.Errorf("failed to check handle: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &users); err != nil {
+		return "", fmt.Errorf("failed to parse handle check: %w", err)
+	}
+
+	if len(users) > 0 {
+		return users[0].ID, nil
+	}
+
+	redirect, err := s.getHandleRedirect(ctx, handle)
+	if err != nil || redirect == nil {
+		return "", err
+	}
+	return redirect.UserID, nil
+}
+
+// getHandleRedirect returns the unexpired redirect for a released handle, if any
+func (s *UserService) getHandleRedirect(ctx context.Context, handle string) (*models.HandleRedirect, error) {
+	var redirects []models.HandleRedirect
+	data, _, err := database.GetClient().From("handle_redirects").
+		Select("*", "exact", false).
+		Eq("handle", handle).
+		Gt("expires_at", time.Now().UTC().Format(time.RFC3339)).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to check handle redirect: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &redirects); err != nil {
+		return nil, fmt.Errorf("failed to parse handle redirect: %w", err)
+	}
+
+	if len(redirects) == 0 {
+		return nil, nil
+	}
+	return &redirects[0], nil
+}
+
+// isUniqueViolation reports whether a database error is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "23505") || strings.Contains(msg, "duplicate key")
+}
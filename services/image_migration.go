@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+	"pesxchange-backend/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	imageMigrationBatchSize  = 20               // Items per batch; legacy rows carry megabytes of base64
+	imageMigrationStaleAfter = 10 * time.Minute // A running migration with no checkpoint for this long is resumable
+)
+
+// Image migration statuses
+const (
+	ImageMigrationRunning   = "running"
+	ImageMigrationCompleted = "completed"
+	ImageMigrationFailed    = "failed"
+)
+
+type ImageMigrationService struct {
+	uploadService *UploadService
+}
+
+func NewImageMigrationService() *ImageMigrationService {
+	return &ImageMigrationService{
+		uploadService: NewUploadService(),
+	}
+}
+
+// Start resumes the latest unfinished migration from its checkpoint, or begins a new one.
+// Call Run with the returned migration to do the work.
+func (s *ImageMigrationService) Start(ctx context.Context) (*models.ImageMigration, error) {
+	client := database.GetClient()
+
+	latest, err := s.getLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if latest != nil && latest.Status != ImageMigrationCompleted {
+		if latest.Status == ImageMigrationRunning && time.Since(latest.UpdatedAt) < imageMigrationStaleAfter {
+			return nil, fmt.Errorf("migration already running")
+		}
+
+		// Resume from the last checkpoint
+		latest.Status = ImageMigrationRunning
+		latest.LastError = nil
+		latest.UpdatedAt = time.Now()
+		if err := s.checkpoint(ctx, latest); err != nil {
+			return nil, err
+		}
+		return latest, nil
+	}
+
+	now := time.Now()
+	migration := &models.ImageMigration{
+		ID:        uuid.New().String(),
+		Status:    ImageMigrationRunning,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+
+	_, _, err = client.From("image_migrations").
+		Insert(migration, false, "", "", "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration: %w", err)
+	}
+
+	return migration, nil
+}
+
+// Run walks all items in ID order from the migration's cursor, converting inline base64
+// images to storage objects and rewriting each item's Images array. Progress is
+// checkpointed after every batch; progress, if set, is called after each checkpoint.
+func (s *ImageMigrationService) Run(ctx context.Context, migration *models.ImageMigration, progress func(*models.ImageMigration)) error {
+	err := s.run(ctx, migration, progress)
+
+	migration.UpdatedAt = time.Now()
+	if err != nil {
+		msg := err.Error()
+		migration.Status = ImageMigrationFailed
+		migration.LastError = &msg
+	} else {
+		migration.Status = ImageMigrationCompleted
+		migration.CompletedAt = &migration.UpdatedAt
+	}
+
+	// Record the outcome even if ctx was cancelled, so the run can be resumed
+	if cpErr := s.checkpoint(context.Background(), migration); cpErr != nil && err == nil {
+		err = cpErr
+	}
+	if progress != nil {
+		progress(migration)
+	}
+
+	return err
+}
+
+func (s *ImageMigrationService) run(ctx context.Context, migration *models.ImageMigration, progress func(*models.ImageMigration)) error {
+	client := database.GetClient()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("migration interrupted: %w", err)
+		}
+
+		query := client.From("items").
+			Select("id,seller_id,images,updated_at", "exact", false).
+			Order("id", &postgrestAscending).
+			Limit(imageMigrationBatchSize, "")
+		if migration.Cursor != nil {
+			query = query.Gt("id", *migration.Cursor)
+		}
+
+		data, _, err := query.Execute()
+		if err != nil {
+			return fmt.Errorf("failed to get items: %w", err)
+		}
+
+		var items []models.Item
+		if err := json.Unmarshal(data, &items); err != nil {
+			return fmt.Errorf("failed to parse items: %w", err)
+		}
+
+		if len(items) == 0 {
+			return nil
+		}
+
+		for _, item := range items {
+			// Stop before the cursor moves past an item that wasn't fully migrated
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("migration interrupted: %w", err)
+			}
+			if err := s.migrateItem(ctx, migration, &item); err != nil {
+				return err
+			}
+			itemID := item.ID
+			migration.ItemsScanned++
+			migration.Cursor = &itemID
+		}
+
+		migration.UpdatedAt = time.Now()
+		if err := s.checkpoint(ctx, migration); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(migration)
+		}
+	}
+}
+
+// migrateItem converts one item's base64 images. Images that can't be decoded or are
+// rejected keep their original data and are recorded as failures; any other error, including
+// an interrupt, aborts the migration so the item is retried when it resumes.
+func (s *ImageMigrationService) migrateItem(ctx context.Context, migration *models.ImageMigration, item *models.Item) error {
+	images := make([]string, len(item.Images))
+	copy(images, item.Images)
+
+	type failure struct {
+		index  int
+		reason string
+	}
+	var failures []failure
+
+	converted := 0
+	for i, img := range item.Images {
+		if !strings.HasPrefix(img, "data:image/") {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("migration interrupted: %w", err)
+		}
+
+		// Same naming as ConvertBase64ToStorage: <item>_<unix>_<index>_<variant>.jpg
+		baseName := fmt.Sprintf("%s_%d_%d", item.ID, time.Now().Unix(), i)
+
+		urls, err := s.uploadService.ConvertBase64Image(ctx, item.SellerID, baseName, img)
+		if err != nil {
+			if ctx.Err() != nil || !isImageRejection(err) {
+				return fmt.Errorf("failed to convert image %d of item %s: %w", i, item.ID, err)
+			}
+			failures = append(failures, failure{index: i, reason: err.Error()})
+			continue
+		}
+
+		images[i] = urls[utils.VariantFull]
+		converted++
+	}
+
+	for _, f := range failures {
+		migration.ImagesFailed++
+		if err := s.recordFailure(ctx, migration.ID, item.ID, f.index, f.reason); err != nil {
+			return err
+		}
+	}
+
+	if converted == 0 {
+		return nil
+	}
+
+	saved, err := s.saveMigratedImages(ctx, item, images)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		// The seller changed the images while they were being converted; the converted
+		// objects are tracked uploads, so upload GC removes them
+		log.Printf("Skipping item %s: its images changed during migration", item.ID)
+		return nil
+	}
+
+	migration.ImagesConverted += converted
+	migration.ItemsUpdated++
+	return nil
+}
+
+// saveMigratedImages replaces an item's images, but only while they are still the ones
+// item was read with, so edits made during the migration aren't overwritten. The update
+// is guarded by updated_at; if that moved without the images changing it is retried.
+func (s *ImageMigrationService) saveMigratedImages(ctx context.Context, item *models.Item, images []string) (bool, error) {
+	client := database.GetClient()
+
+	for {
+		var updated []models.Item
+		data, _, err := client.From("items").
+			Update(map[string]interface{}{
+				"images":        images,
+				"image_details": buildItemImages(ctx, item.ID, images),
+			}, "", "").
+			Eq("id", item.ID).
+			Eq("updated_at", item.UpdatedAt.UTC().Format(time.RFC3339Nano)).
+			Execute()
+
+		if err != nil {
+			return false, fmt.Errorf("failed to update item %s: %w", item.ID, err)
+		}
+
+		if err := json.Unmarshal(data, &updated); err != nil {
+			return false, fmt.Errorf("failed to parse item %s: %w", item.ID, err)
+		}
+
+		if len(updated) > 0 {
+			return true, nil
+		}
+
+		var current []models.Item
+		data, _, err = client.From("items").
+			Select("id,images,updated_at", "exact", false).
+			Eq("id", item.ID).
+			Execute()
+
+		if err != nil {
+			return false, fmt.Errorf("failed to get item %s: %w", item.ID, err)
+		}
+
+		if err := json.Unmarshal(data, &current); err != nil {
+			return false, fmt.Errorf("failed to parse item %s: %w", item.ID, err)
+		}
+
+		if len(current) == 0 || !equalStrings(current[0].Images, item.Images) || current[0].UpdatedAt.Equal(item.UpdatedAt) {
+			return false, nil
+		}
+		item.UpdatedAt = current[0].UpdatedAt
+	}
+}
+
+// isImageRejection reports whether ConvertBase64Image failed because of the image itself,
+// rather than storage or the database, so retrying it would fail the same way
+func isImageRejection(err error) bool {
+	switch err.Error() {
+	case "invalid format", "exceeds size limit", "decode failed", "decoded size exceeds 5MB",
+		"invalid image type", "image is banned":
+		return true
+	}
+	return strings.HasPrefix(err.Error(), "processing failed")
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// GetStatus returns the latest migration and its failures
+func (s *ImageMigrationService) GetStatus(ctx context.Context) (*models.ImageMigrationStatus, error) {
+	client := database.GetClient()
+
+	status := &models.ImageMigrationStatus{
+		Failures: []models.ImageMigrationFailure{},
+	}
+
+	latest, err := s.getLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil {
+		return status, nil
+	}
+	status.Migration = latest
+
+	data, _, err := client.From("image_migration_failures").
+		Select("*", "exact", false).
+		Eq("migration_id", latest.ID).
+		Order("created_at", &postgrestAscending).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration failures: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &status.Failures); err != nil {
+		return nil, fmt.Errorf("failed to parse migration failures: %w", err)
+	}
+
+	return status, nil
+}
+
+func (s *ImageMigrationService) getLatest(ctx context.Context) (*models.ImageMigration, error) {
+	client := database.GetClient()
+
+	var migrations []models.ImageMigration
+	data, _, err := client.From("image_migrations").
+		Select("*", "exact", false).
+		Order("started_at", nil).
+		Limit(1, "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &migrations); err != nil {
+		return nil, fmt.Errorf("failed to parse migration: %w", err)
+	}
+
+	if len(migrations) == 0 {
+		return nil, nil
+	}
+
+	return &migrations[0], nil
+}
+
+// checkpoint persists the migration's cursor and counters
+func (s *ImageMigrationService) checkpoint(ctx context.Context, migration *models.ImageMigration) error {
+	_, _, err := database.GetClient().From("image_migrations").
+		Update(migration, "", "").
+		Eq("id", migration.ID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to save migration checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+func (s *ImageMigrationService) recordFailure(ctx context.Context, migrationID, itemID string, index int, reason string) error {
+	failure := &models.ImageMigrationFailure{
+		ID:          uuid.New().String(),
+		MigrationID: migrationID,
+		ItemID:      itemID,
+		ImageIndex:  index,
+		Error:       reason,
+		CreatedAt:   time.Now(),
+	}
+
+	_, _, err := database.GetClient().From("image_migration_failures").
+		Insert(failure, false, "", "", "").
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to record migration failure: %w", err)
+	}
+
+	return nil
+}
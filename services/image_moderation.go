@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+	"pesxchange-backend/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	nearDuplicateDistance  = 7   // Max Hamming distance between hashes to flag; HashBands finds every match up to 7
+	maxDuplicateCandidates = 500 // Band matches compared per image
+)
+
+// Image moderation flag statuses
+const (
+	ImageFlagOpen      = "open"
+	ImageFlagDismissed = "dismissed"
+	ImageFlagBanned    = "banned"
+)
+
+type ImageModerationService struct{}
+
+func NewImageModerationService() *ImageModerationService {
+	return &ImageModerationService{}
+}
+
+// CheckItemImages flags item images that are near-duplicates of images uploaded by other
+// users, adding them to the moderation queue. Images without a tracked upload are skipped.
+func (s *ImageModerationService) CheckItemImages(ctx context.Context, itemID, sellerID string, images []string) error {
+	client := database.GetClient()
+
+	if len(images) == 0 {
+		return nil
+	}
+
+	// Uploads are tracked by their full variant URL
+	fullURLs := make([]string, 0, len(images))
+	for _, img := range images {
+		fullURLs = append(fullURLs, utils.VariantURL(img, utils.VariantFull))
+	}
+
+	var uploads []models.Upload
+	data, _, err := client.From("uploads").
+		Select("id,url,phash", "exact", false).
+		In("url", fullURLs).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to get uploads: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &uploads); err != nil {
+		return fmt.Errorf("failed to parse uploads: %w", err)
+	}
+
+	existing, err := s.getItemFlagKeys(ctx, itemID)
+	if err != nil {
+		return err
+	}
+
+	for _, upload := range uploads {
+		if upload.PHash == nil || upload.URL == nil {
+			continue
+		}
+		hash, err := utils.ParseHash(*upload.PHash)
+		if err != nil {
+			continue
+		}
+
+		matches, err := findNearDuplicates(ctx, hash, sellerID)
+		if err != nil {
+			return err
+		}
+
+		for _, match := range matches {
+			if existing[*upload.URL+"|"+match.upload.ID] {
+				continue
+			}
+
+			matchedID := match.upload.ID
+			flag := &models.ImageModerationFlag{
+				ID:              uuid.New().String(),
+				ItemID:          itemID,
+				SellerID:        sellerID,
+				ImageURL:        *upload.URL,
+				PHash:           *upload.PHash,
+				MatchedUploadID: &matchedID,
+				MatchedUserID:   &match.upload.UserID,
+				MatchedURL:      match.upload.URL,
+				Distance:        match.distance,
+				Status:          ImageFlagOpen,
+				CreatedAt:       time.Now(),
+			}
+
+			_, _, err := client.From("image_moderation_flags").
+				Insert(flag, false, "", "", "").
+				Execute()
+
+			if err != nil {
+				return fmt.Errorf("failed to flag image: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetFlags returns moderation flags with the given status, oldest first
+func (s *ImageModerationService) GetFlags(ctx context.Context, status string, limit, offset int) ([]models.ImageModerationFlag, error) {
+	client := database.GetClient()
+
+	var flags []models.ImageModerationFlag
+	data, _, err := client.From("image_moderation_flags").
+		Select("*", "exact", false).
+		Eq("status", status).
+		Order("created_at", &postgrestAscending).
+		Range(offset, offset+limit-1, "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image flags: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return nil, fmt.Errorf("failed to parse image flags: %w", err)
+	}
+
+	return flags, nil
+}
+
+// ReviewFlag records a moderator's decision. Banning a flag adds the flagged image's hash
+// to the banned list so exact re-uploads can be rejected.
+func (s *ImageModerationService) ReviewFlag(ctx context.Context, flagID, reviewerID string, req *models.ReviewImageFlagRequest) (*models.ImageModerationFlag, error) {
+	client := database.GetClient()
+
+	if req.Status != ImageFlagDismissed && req.Status != ImageFlagBanned {
+		return nil, fmt.Errorf("invalid status")
+	}
+
+	var flags []models.ImageModerationFlag
+	data, _, err := client.From("image_moderation_flags").
+		Select("*", "exact", false).
+		Eq("id", flagID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image flag: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return nil, fmt.Errorf("failed to parse image flag: %w", err)
+	}
+
+	if len(flags) == 0 {
+		return nil, fmt.Errorf("flag not found")
+	}
+	flag := &flags[0]
+
+	if req.Status == ImageFlagBanned {
+		ban := &models.BannedImageHash{
+			PHash:     flag.PHash,
+			Reason:    req.Reason,
+			BannedBy:  &reviewerID,
+			CreatedAt: time.Now(),
+		}
+
+		_, _, err := client.From("banned_image_hashes").
+			Upsert(ban, "phash", "", "").
+			Execute()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to ban image: %w", err)
+		}
+	}
+
+	now := time.Now()
+	flag.Status = req.Status
+	flag.ReviewedBy = &reviewerID
+	flag.ReviewedAt = &now
+
+	_, _, err = client.From("image_moderation_flags").
+		Update(map[string]interface{}{
+			"status":      flag.Status,
+			"reviewed_by": reviewerID,
+			"reviewed_at": now,
+		}, "", "").
+		Eq("id", flag.ID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to review image flag: %w", err)
+	}
+
+	return flag, nil
+}
+
+// getItemFlagKeys returns "image_url|matched_upload_id" for flags already raised on an item
+func (s *ImageModerationService) getItemFlagKeys(ctx context.Context, itemID string) (map[string]bool, error) {
+	client := database.GetClient()
+
+	var flags []models.ImageModerationFlag
+	data, _, err := client.From("image_moderation_flags").
+		Select("image_url,matched_upload_id", "exact", false).
+		Eq("item_id", itemID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image flags: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return nil, fmt.Errorf("failed to parse image flags: %w", err)
+	}
+
+	keys := make(map[string]bool, len(flags))
+	for _, f := range flags {
+		if f.MatchedUploadID != nil {
+			keys[f.ImageURL+"|"+*f.MatchedUploadID] = true
+		}
+	}
+
+	return keys, nil
+}
+
+type duplicateMatch struct {
+	upload   models.Upload
+	distance int
+}
+
+// findNearDuplicates returns completed uploads by users other than ownerID whose hash is
+// within nearDuplicateDistance of hash
+func findNearDuplicates(ctx context.Context, hash uint64, ownerID string) ([]duplicateMatch, error) {
+	client := database.GetClient()
+
+	bands := utils.HashBands(hash)
+	bandValues := make([]string, len(bands))
+	for i, b := range bands {
+		bandValues[i] = strconv.Itoa(b)
+	}
+
+	var candidates []models.Upload
+	data, _, err := client.From("uploads").
+		Select("id,user_id,url,phash", "exact", false).
+		Overlaps("phash_bands", bandValues).
+		Not("user_id", "eq", ownerID).
+		Eq("status", UploadStatusCompleted).
+		Order("created_at", nil).
+		Limit(maxDuplicateCandidates, "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find similar images: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &candidates); err != nil {
+		return nil, fmt.Errorf("failed to parse similar images: %w", err)
+	}
+
+	var matches []duplicateMatch
+	for _, candidate := range candidates {
+		if candidate.PHash == nil {
+			continue
+		}
+		other, err := utils.ParseHash(*candidate.PHash)
+		if err != nil {
+			continue
+		}
+		if distance := utils.HashDistance(hash, other); distance <= nearDuplicateDistance {
+			matches = append(matches, duplicateMatch{upload: candidate, distance: distance})
+		}
+	}
+
+	return matches, nil
+}
+
+// isBannedHash reports whether a perceptual hash exactly matches a banned image
+func isBannedHash(ctx context.Context, hash uint64) (bool, error) {
+	client := database.GetClient()
+
+	data, _, err := client.From("banned_image_hashes").
+		Select("phash", "exact", false).
+		Eq("phash", utils.FormatHash(hash)).
+		Limit(1, "").
+		Execute()
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check banned images: %w", err)
+	}
+
+	var bans []models.BannedImageHash
+	if err := json.Unmarshal(data, &bans); err != nil {
+		return false, fmt.Errorf("failed to parse banned images: %w", err)
+	}
+
+	return len(bans) > 0, nil
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+	"pesxchange-backend/storage"
+	"pesxchange-backend/utils"
+)
+
+const (
+	imageCacheMaxBytes = 64 * 1024 * 1024 // Memory budget for cached images
+	imageCacheTTL      = 5 * time.Minute  // Bounds how long an edited item serves its old images
+)
+
+// ImageContent is an item image ready to be served
+type ImageContent struct {
+	Data         []byte
+	ContentType  string
+	ETag         string // Strong validator: quoted hash of Data
+	LastModified time.Time
+	RedirectURL  string // Set instead of Data for images hosted outside our storage
+}
+
+// ImageProxyService serves item images from storage or legacy inline data, caching hot images in memory
+type ImageProxyService struct {
+	store storage.ObjectStore
+	cache *imageCache
+}
+
+func NewImageProxyService() *ImageProxyService {
+	return &ImageProxyService{
+		store: storage.GetStore(ItemImagesBucket),
+		cache: newImageCache(imageCacheMaxBytes, imageCacheTTL),
+	}
+}
+
+// GetItemImage returns the image at index of an item. size selects a variant (see
+// utils.ImageVariants); images stored without that variant are resized on the fly.
+// An empty size serves the image as stored.
+func (s *ImageProxyService) GetItemImage(ctx context.Context, itemID string, index int, size string) (*ImageContent, error) {
+	cacheKey := fmt.Sprintf("%s/%d/%s", itemID, index, size)
+	if content, ok := s.cache.get(cacheKey); ok {
+		return content, nil
+	}
+
+	// Only the image list is needed, not the seller lookup GetItemByID does
+	var items []models.Item
+	data, _, err := database.GetClient().From("items").
+		Select("images,updated_at", "exact", false).
+		Eq("id", itemID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse item: %w", err)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("item not found")
+	}
+	item := &items[0]
+
+	if index < 0 || index >= len(item.Images) {
+		return nil, fmt.Errorf("image not found")
+	}
+
+	var content *ImageContent
+	if src := item.Images[index]; strings.HasPrefix(src, "data:image/") {
+		content, err = s.inlineImage(src, size, item.UpdatedAt)
+	} else {
+		content, err = s.storedImage(ctx, src, size, item.UpdatedAt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.put(cacheKey, content)
+	return content, nil
+}
+
+// inlineImage decodes a legacy base64 data URL
+func (s *ImageProxyService) inlineImage(dataURL, size string, lastModified time.Time) (*ImageContent, error) {
+	// Anything that isn't really an allowed image type could be served as active content
+	data, err := decodeImageDataURL(dataURL)
+	if err != nil {
+		return nil, fmt.Errorf("image not found")
+	}
+
+	content := &ImageContent{Data: data, ContentType: http.DetectContentType(data), LastModified: lastModified}
+	if size != "" {
+		renderVariant(content, size)
+	}
+	content.ETag = contentETag(content.Data)
+	return content, nil
+}
+
+// storedImage fetches an image from object storage, preferring a stored variant over resizing
+func (s *ImageProxyService) storedImage(ctx context.Context, url, size string, lastModified time.Time) (*ImageContent, error) {
+	render := false
+	if size != "" {
+		if variant := utils.VariantURL(url, size); variant != url || isVariantOf(url, size) {
+			url = variant
+		} else {
+			render = true // Uploaded before variants existed
+		}
+	}
+
+	key, ok := storage.KeyFromURL(s.store, url)
+	if !ok {
+		return &ImageContent{RedirectURL: url}, nil
+	}
+
+	obj, err := s.store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("image not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	content := &ImageContent{Data: obj.Data, ContentType: obj.ContentType, LastModified: obj.LastModified}
+	if content.LastModified.IsZero() {
+		content.LastModified = lastModified
+	}
+	if content.ContentType == "" || content.ContentType == "application/octet-stream" {
+		content.ContentType = http.DetectContentType(content.Data)
+	}
+	if render {
+		renderVariant(content, size)
+	}
+	content.ETag = contentETag(content.Data)
+	return content, nil
+}
+
+// renderVariant replaces content with a resized variant, keeping the original if it can't be decoded
+func renderVariant(content *ImageContent, size string) {
+	data, err := utils.RenderVariant(content.Data, size, MaxImageDimension)
+	if err != nil {
+		log.Printf("Failed to render %s image variant: %v", size, err)
+		return
+	}
+	content.Data = data
+	content.ContentType = utils.ProcessedImageContentType
+}
+
+// isVariantOf reports whether url already names the given variant of a processed image
+func isVariantOf(url, variant string) bool {
+	return strings.HasSuffix(url, "_"+variant+utils.ProcessedImageExtension)
+}
+
+// contentETag returns a strong ETag derived from the bytes served
+func contentETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// imageCache is an LRU cache of served images bounded by total size, with entries expiring after ttl
+type imageCache struct {
+	maxBytes int64
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	bytes   int64
+	order   *list.List // Most recently used at the front
+	entries map[string]*list.Element
+}
+
+type imageCacheEntry struct {
+	key       string
+	content   *ImageContent
+	expiresAt time.Time
+}
+
+func newImageCache(maxBytes int64, ttl time.Duration) *imageCache {
+	return &imageCache{
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *imageCache) get(key string) (*ImageContent, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*imageCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.content, true
+}
+
+func (c *imageCache) put(key string, content *ImageContent) {
+	size := int64(len(content.Data))
+	if size > c.maxBytes/8 {
+		return // One huge legacy image shouldn't flush the whole cache
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	c.entries[key] = c.order.PushFront(&imageCacheEntry{
+		key:       key,
+		content:   content,
+		expiresAt: time.Now().Add(c.ttl),
+	})
+	c.bytes += size
+
+	for c.bytes > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove evicts an entry. Callers must hold c.mu.
+func (c *imageCache) remove(elem *list.Element) {
+	entry := c.order.Remove(elem).(*imageCacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= int64(len(entry.content.Data))
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+)
+
+// GetFollowingFeed lists available items from the sellers userID follows, newest first, in
+// the same shape as GetItems
+func (s *ItemService) GetFollowingFeed(ctx context.Context, userID string, limit, offset int) ([]models.Item, int, error) {
+	followingIDs, err := s.followService.GetFollowingIDs(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	blockedIDs, err := s.blockService.GetBlockedUserIDs(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sellerIDs := make([]string, 0, len(followingIDs))
+	for _, id := range followingIDs {
+		if !blockedIDs[id] {
+			sellerIDs = append(sellerIDs, id)
+		}
+	}
+	if len(sellerIDs) == 0 {
+		return []models.Item{}, 0, nil
+	}
+
+	var items []models.Item
+	data, total, err := database.GetClient().From("items").
+		Select(itemListColumns, "exact", false).
+		In("seller_id", sellerIDs).
+		Eq("is_available", "true").
+		Eq("seller_away", "false").
+		Order("created_at", nil).
+		Range(offset, offset+limit-1, "").
+		Execute()
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get feed items: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse feed items: %w", err)
+	}
+
+	// Process images to prevent huge responses
+	s.processItemImages(items)
+
+	return items, int(total), nil
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+	"pesxchange-backend/utils"
+
+	"github.com/google/uuid"
+)
+
+// ReorderImages puts an item's images in the order of imageIDs; the first becomes the cover
+func (s *ItemService) ReorderImages(ctx context.Context, itemID, sellerID string, imageIDs []string) ([]models.ItemImage, error) {
+	item, err := s.getItemForImageEdit(ctx, itemID, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]models.ItemImage, len(item.ImageDetails))
+	for _, img := range item.ImageDetails {
+		byID[img.ID] = img
+	}
+
+	if len(imageIDs) != len(byID) {
+		return nil, fmt.Errorf("image IDs must match the item's images")
+	}
+
+	images := make([]models.ItemImage, 0, len(imageIDs))
+	for _, id := range imageIDs {
+		img, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("image IDs must match the item's images")
+		}
+		images = append(images, img)
+		delete(byID, id) // Rejects duplicate IDs
+	}
+
+	return s.saveItemImages(ctx, itemID, images)
+}
+
+// SetCoverImage moves an image to the front of the item's images
+func (s *ItemService) SetCoverImage(ctx context.Context, itemID, sellerID, imageID string) ([]models.ItemImage, error) {
+	item, err := s.getItemForImageEdit(ctx, itemID, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := findItemImage(item.ImageDetails, imageID)
+	if index < 0 {
+		return nil, fmt.Errorf("image not found")
+	}
+
+	images := make([]models.ItemImage, 0, len(item.ImageDetails))
+	images = append(images, item.ImageDetails[index])
+	images = append(images, item.ImageDetails[:index]...)
+	images = append(images, item.ImageDetails[index+1:]...)
+
+	return s.saveItemImages(ctx, itemID, images)
+}
+
+// UpdateImage replaces an image's file and/or caption, keeping its ID and position
+func (s *ItemService) UpdateImage(ctx context.Context, itemID, sellerID, imageID string, req *models.UpdateItemImageRequest) ([]models.ItemImage, error) {
+	item, err := s.getItemForImageEdit(ctx, itemID, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := findItemImage(item.ImageDetails, imageID)
+	if index < 0 {
+		return nil, fmt.Errorf("image not found")
+	}
+	img := item.ImageDetails[index]
+
+	if req.URL != nil && *req.URL != img.URL {
+		if strings.HasPrefix(*req.URL, "data:") {
+			return nil, fmt.Errorf("inline images are not supported")
+		}
+		if err := s.uploadService.CheckAttachable([]string{*req.URL}); err != nil {
+			return nil, err
+		}
+
+		replacement := buildItemImages(ctx, itemID, []string{*req.URL})[0]
+		replacement.ID = img.ID
+		replacement.Caption = img.Caption
+		img = replacement
+	}
+
+	if req.Caption != nil {
+		img.Caption = strings.TrimSpace(*req.Caption)
+	}
+
+	item.ImageDetails[index] = img
+	images, err := s.saveItemImages(ctx, itemID, item.ImageDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	// A replaced file may have been copied from another seller
+	if req.URL != nil {
+		if err := s.imageModerationService.CheckItemImages(ctx, itemID, sellerID, []string{img.URL}); err != nil {
+			log.Printf("Failed to check item %s images for duplicates: %v", itemID, err)
+		}
+	}
+
+	return images, nil
+}
+
+// RemoveImage removes an image from an item. The stored objects are left for upload
+// garbage collection, which deletes them once no item references them.
+func (s *ItemService) RemoveImage(ctx context.Context, itemID, sellerID, imageID string) ([]models.ItemImage, error) {
+	item, err := s.getItemForImageEdit(ctx, itemID, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := findItemImage(item.ImageDetails, imageID)
+	if index < 0 {
+		return nil, fmt.Errorf("image not found")
+	}
+
+	images := append(item.ImageDetails[:index:index], item.ImageDetails[index+1:]...)
+	return s.saveItemImages(ctx, itemID, images)
+}
+
+// getItemForImageEdit loads an item owned by sellerID with its structured images
+func (s *ItemService) getItemForImageEdit(ctx context.Context, itemID, sellerID string) (*models.Item, error) {
+	client := database.GetClient()
+
+	var items []models.Item
+	data, _, err := client.From("items").
+		Select("id,seller_id,images,image_details", "exact", false).
+		Eq("id", itemID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse item: %w", err)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("item not found")
+	}
+
+	item := &items[0]
+	if item.SellerID != sellerID {
+		return nil, fmt.Errorf("unauthorized: not the item owner")
+	}
+
+	// Rewriting images would replace inline data with its serving URL, so these items
+	// must go through the base64 image migration first
+	for _, img := range item.Images {
+		if strings.HasPrefix(img, "data:image/") {
+			return nil, fmt.Errorf("item has inline images")
+		}
+	}
+
+	if len(item.ImageDetails) == 0 {
+		item.ImageDetails = buildItemImages(ctx, item.ID, item.Images)
+	}
+
+	return item, nil
+}
+
+// saveItemImages renumbers images and writes them along with the legacy images array
+func (s *ItemService) saveItemImages(ctx context.Context, itemID string, images []models.ItemImage) ([]models.ItemImage, error) {
+	client := database.GetClient()
+
+	urls := make([]string, len(images))
+	for i := range images {
+		images[i].Order = i
+		urls[i] = images[i].URL
+	}
+
+	_, _, err := client.From("items").
+		Update(map[string]interface{}{
+			"images":        urls,
+			"image_details": images,
+			"updated_at":    time.Now(),
+		}, "", "").
+		Eq("id", itemID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update item images: %w", err)
+	}
+
+	return images, nil
+}
+
+// buildItemImages turns image URLs into structured images in the same order, taking
+// dimensions and placeholders from the uploads they came from
+func buildItemImages(ctx context.Context, itemID string, urls []string) []models.ItemImage {
+	uploads := make(map[string]models.Upload)
+
+	fullURLs := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if !strings.HasPrefix(url, "data:") {
+			fullURLs = append(fullURLs, utils.VariantURL(url, utils.VariantFull))
+		}
+	}
+
+	if len(fullURLs) > 0 {
+		var rows []models.Upload
+		data, _, err := database.GetClient().From("uploads").
+			Select("url,width,height,blurhash", "exact", false).
+			In("url", fullURLs).
+			Execute()
+
+		// Metadata is optional; images still work without it
+		if err == nil && json.Unmarshal(data, &rows) == nil {
+			for _, row := range rows {
+				if row.URL != nil {
+					uploads[*row.URL] = row
+				}
+			}
+		} else if err != nil {
+			log.Printf("Failed to get image metadata for item %s: %v", itemID, err)
+		}
+	}
+
+	images := make([]models.ItemImage, len(urls))
+	for i, url := range urls {
+		images[i] = newItemImage(itemID, i, url)
+
+		if upload, ok := uploads[utils.VariantURL(url, utils.VariantFull)]; ok {
+			if upload.Width != nil {
+				images[i].Width = *upload.Width
+			}
+			if upload.Height != nil {
+				images[i].Height = *upload.Height
+			}
+			if upload.Blurhash != nil {
+				images[i].Blurhash = *upload.Blurhash
+			}
+		}
+	}
+
+	return images
+}
+
+// mergeItemImages builds the structured images for a new list of image URLs, keeping the
+// existing entry (ID, caption, dimensions) for each URL the item already had so edits that
+// resend the same images don't reset them. Only new URLs get new entries.
+func mergeItemImages(ctx context.Context, item *models.Item, urls []string) []models.ItemImage {
+	existing := make(map[string][]models.ItemImage)
+	if details := itemImageDetails(item); len(details) == len(item.Images) {
+		for i, url := range item.Images {
+			// Inline images are addressed by position, so they are always rebuilt
+			if !strings.HasPrefix(url, "data:") {
+				existing[url] = append(existing[url], details[i])
+			}
+		}
+	}
+
+	images := make([]models.ItemImage, len(urls))
+	var newURLs []string
+	var newPositions []int
+	for i, url := range urls {
+		if kept := existing[url]; len(kept) > 0 {
+			images[i] = kept[0]
+			existing[url] = kept[1:]
+			continue
+		}
+		newURLs = append(newURLs, url)
+		newPositions = append(newPositions, i)
+	}
+
+	if len(newURLs) > 0 {
+		for j, img := range buildItemImages(ctx, item.ID, newURLs) {
+			images[newPositions[j]] = img
+		}
+	}
+
+	seen := make(map[string]bool, len(images))
+	for i := range images {
+		images[i].Order = i
+		if seen[images[i].ID] {
+			images[i].ID = uuid.New().String()
+		}
+		seen[images[i].ID] = true
+	}
+
+	return images
+}
+
+// itemImageDetails returns an item's structured images, deriving them from the legacy
+// images array for items that predate image_details
+func itemImageDetails(item *models.Item) []models.ItemImage {
+	if len(item.ImageDetails) > 0 {
+		return item.ImageDetails
+	}
+
+	images := make([]models.ItemImage, len(item.Images))
+	for i, url := range item.Images {
+		images[i] = newItemImage(item.ID, i, url)
+	}
+	return images
+}
+
+// newItemImage builds the structured image for a URL at position index. The ID is derived
+// from the item, position and URL so images derived from legacy data keep a stable ID.
+func newItemImage(itemID string, index int, url string) models.ItemImage {
+	img := models.ItemImage{
+		ID:    uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("%s/%d/%s", itemID, index, url))).String(),
+		URL:   url,
+		Order: index,
+	}
+
+	switch {
+	case strings.HasPrefix(url, "data:image/"):
+		// Inline legacy images are served by GetItemImage
+		img.URL = fmt.Sprintf("/api/items/%s/image/%d", itemID, index)
+		img.Variants = map[string]string{utils.VariantFull: img.URL}
+	case utils.VariantURL(url, utils.VariantThumb) != url || isVariantOf(url, utils.VariantThumb):
+		img.Variants = make(map[string]string, len(utils.ImageVariants))
+		for _, variant := range utils.ImageVariants {
+			img.Variants[variant.Name] = utils.VariantURL(url, variant.Name)
+		}
+	default:
+		// Uploaded before variants existed
+		img.Variants = map[string]string{utils.VariantFull: url}
+	}
+
+	return img
+}
+
+func findItemImage(images []models.ItemImage, imageID string) int {
+	for i, img := range images {
+		if img.ID == imageID {
+			return i
+		}
+	}
+	return -1
+}
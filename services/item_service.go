@@ -4,25 +4,45 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	"pesxchange-backend/database"
 	"pesxchange-backend/models"
+	"pesxchange-backend/utils"
 
 	"github.com/google/uuid"
 )
 
-type ItemService struct{}
+// Columns selected for item listings (GetItems and the following feed)
+const itemListColumns = "id,title,description,price,location,condition,seller_id,images,image_details,category,created_at,updated_at,is_available,views"
+
+type ItemService struct {
+	blockService           *BlockService
+	uploadService          *UploadService
+	imageModerationService *ImageModerationService
+	followService          *FollowService
+}
 
 func NewItemService() *ItemService {
-	return &ItemService{}
+	return &ItemService{
+		blockService:           NewBlockService(),
+		uploadService:          NewUploadService(),
+		imageModerationService: NewImageModerationService(),
+		followService:          NewFollowService(),
+	}
 }
 
 // CreateItem creates a new item listing
 func (s *ItemService) CreateItem(ctx context.Context, req *models.CreateItemRequest) (*models.Item, error) {
 	client := database.GetClient()
 	
+	// Direct uploads can only be attached once they have been verified
+	if err := s.uploadService.CheckAttachable(req.Images); err != nil {
+		return nil, err
+	}
+	
 	now := time.Now()
 	
 	// Set default values to match Node.js API
@@ -36,6 +56,19 @@ func (s *ItemService) CreateItem(ctx context.Context, req *models.CreateItemRequ
 		views = *req.Views
 	}
 	
+	// Graduated students can only keep a few items listed
+	if isAvailable {
+		if err := checkAlumniListingLimit(ctx, req.SellerID); err != nil {
+			return nil, err
+		}
+	}
+	
+	// Items posted while away stay hidden until the seller is back
+	vacation, err := getVacation(ctx, req.SellerID)
+	if err != nil {
+		return nil, err
+	}
+	
 	// Set default location if empty
 	location := strings.TrimSpace(req.Location)
 	if location == "" {
@@ -57,10 +90,12 @@ func (s *ItemService) CreateItem(ctx context.Context, req *models.CreateItemRequ
 		CreatedAt:   now,
 		UpdatedAt:   now,
 		Category:    req.Category,
+		SellerAway:  vacation != nil,
 	}
+	item.ImageDetails = buildItemImages(ctx, item.ID, req.Images)
 	
 	var newItems []models.Item
-	_, _, err := client.From("items").
+	_, _, err = client.From("items").
 		Insert(item, false, "", "", "").
 		Execute()
 	
@@ -68,6 +103,16 @@ func (s *ItemService) CreateItem(ctx context.Context, req *models.CreateItemRequ
 		return nil, fmt.Errorf("failed to create item: %w", err)
 	}
 	
+	// Queue images copied from other sellers for moderation; this never blocks the listing
+	if err := s.imageModerationService.CheckItemImages(ctx, item.ID, item.SellerID, item.Images); err != nil {
+		log.Printf("Failed to check item %s images for duplicates: %v", item.ID, err)
+	}
+	
+	// Let followers know about the new listing
+	if item.IsAvailable && !item.SellerAway {
+		s.followService.notifyFollowersAsync(item)
+	}
+	
 	if len(newItems) > 0 {
 		return &newItems[0], nil
 	}
@@ -80,6 +125,7 @@ func (s *ItemService) processItemImages(items []models.Item) {
 		// Quick check for empty images
 		if len(items[i].Images) == 0 {
 			items[i].ImageURLs = []string{}
+			items[i].ImageDetails = []models.ItemImage{}
 			continue
 		}
 		
@@ -97,13 +143,21 @@ func (s *ItemService) processItemImages(items []models.Item) {
 			if len(img) > 500 && strings.HasPrefix(img, "data:image/") {
 				continue
 			} else {
-				// Keep URLs and small images as is
-				processedImages = append(processedImages, img)
+				// Send thumbnails for processed uploads; keep other URLs and small images as is
+				processedImages = append(processedImages, utils.VariantURL(img, utils.VariantThumb))
 			}
 		}
 		
+		// Structured images are trimmed the same way; their variants include the thumbnail.
+		// Stored details keep their IDs and captions; only items without them are derived.
+		details := itemImageDetails(&items[i])
+		if len(details) > maxImages {
+			details = details[:maxImages]
+		}
+		
 		items[i].Images = processedImages
 		items[i].ImageURLs = processedImages
+		items[i].ImageDetails = details
 	}
 }
 
@@ -112,7 +166,7 @@ func (s *ItemService) GetItems(ctx context.Context, limit, offset int, filters m
 	client := database.GetClient()
 	
 	// Select fields - cannot directly join with user_profile, will fetch seller info separately if needed
-	query := client.From("items").Select("id,title,description,price,location,condition,seller_id,images,category,created_at,updated_at,is_available,views", "exact", false)
+	query := client.From("items").Select(itemListColumns, "exact", false)
 	
 	// Apply search filter
 	if search, ok := filters["search"].(string); ok && search != "" {
@@ -142,6 +196,22 @@ func (s *ItemService) GetItems(ctx context.Context, limit, offset int, filters m
 		query = query.Ilike("location", fmt.Sprintf("%%%s%%", location))
 	}
 	
+	// Hide listings from sellers on vacation
+	query = query.Eq("seller_away", "false")
+	
+	// Hide listings from users the viewer has blocked (or been blocked by)
+	excludedSellers := ""
+	if viewerID, ok := filters["viewer_id"].(string); ok && viewerID != "" {
+		if blockedIDs, err := s.blockService.GetBlockedUserIDs(ctx, viewerID); err == nil && len(blockedIDs) > 0 {
+			ids := make([]string, 0, len(blockedIDs))
+			for id := range blockedIDs {
+				ids = append(ids, id)
+			}
+			excludedSellers = fmt.Sprintf("(%s)", strings.Join(ids, ","))
+			query = query.Not("seller_id", "in", excludedSellers)
+		}
+	}
+	
 	// Apply sorting
 	sortBy := "created_at"
 	ascending := false
@@ -192,7 +262,7 @@ func (s *ItemService) GetItems(ctx context.Context, limit, offset int, filters m
 	}
 	
 	// Get proper total count for pagination
-	countQuery := client.From("items").Select("count", "exact", false)
+	countQuery := client.From("items").Select("count", "exact", false).Eq("seller_away", "false")
 	
 	// Apply same filters for count
 	if search, ok := filters["search"].(string); ok && search != "" {
@@ -213,6 +283,9 @@ func (s *ItemService) GetItems(ctx context.Context, limit, offset int, filters m
 	if location, ok := filters["location"].(string); ok && location != "" {
 		countQuery = countQuery.Ilike("location", fmt.Sprintf("%%%s%%", location))
 	}
+	if excludedSellers != "" {
+		countQuery = countQuery.Not("seller_id", "in", excludedSellers)
+	}
 	
 	countData, _, err := countQuery.Execute()
 	if err != nil {
@@ -265,19 +338,22 @@ func (s *ItemService) GetItemByID(ctx context.Context, itemID string) (*models.I
 	if item.SellerID != "" {
 		var sellers []models.User
 		sellerData, _, err := client.From("user_profiles").
-			Select("id, nickname, name, email, avatar_url, rating, location, created_at", "exact", false).
+			Select("id, nickname, name, avatar_url, rating, location, created_at, profile_visibility, vacation_started_at, vacation_until, vacation_message", "exact", false).
 			Eq("id", item.SellerID).
 			Execute()
 		
 		if err == nil && len(sellerData) > 0 {
 			if err := json.Unmarshal(sellerData, &sellers); err == nil && len(sellers) > 0 {
+				RedactUser(&sellers[0])
 				item.Seller = &sellers[0]
+				item.SellerVacation = VacationOf(&sellers[0])
 			}
 		}
 	}
 	
 	// Add backward compatibility mapping
 	item.ImageURLs = item.Images
+	item.ImageDetails = itemImageDetails(item)
 	if item.Category != "" {
 		item.Categories = []string{item.Category}
 	}
@@ -322,14 +398,14 @@ func (s *ItemService) IncrementViews(ctx context.Context, itemID string) error {
 	return nil
 }
 
-// UpdateItem updates an existing item
-func (s *ItemService) UpdateItem(ctx context.Context, itemID, sellerID string, updates map[string]interface{}) (*models.Item, error) {
+// UpdateItem updates an existing item; fields left nil in req are unchanged
+func (s *ItemService) UpdateItem(ctx context.Context, itemID, sellerID string, req *models.UpdateItemRequest) (*models.Item, error) {
 	client := database.GetClient()
 	
 	// Verify ownership
 	var items []models.Item
-	_, _, err := client.From("items").
-		Select("seller_id", "exact", false).
+	data, _, err := client.From("items").
+		Select("id,seller_id,is_available,buyer_id,images,image_details", "exact", false).
 		Eq("id", itemID).
 		Execute()
 	
@@ -337,6 +413,10 @@ func (s *ItemService) UpdateItem(ctx context.Context, itemID, sellerID string, u
 		return nil, fmt.Errorf("failed to verify item ownership: %w", err)
 	}
 	
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse item: %w", err)
+	}
+	
 	if len(items) == 0 {
 		return nil, fmt.Errorf("item not found")
 	}
@@ -345,15 +425,54 @@ func (s *ItemService) UpdateItem(ctx context.Context, itemID, sellerID string, u
 		return nil, fmt.Errorf("unauthorized: not the item owner")
 	}
 	
-	// Remove protected fields
-	delete(updates, "id")
-	delete(updates, "seller_id")
-	delete(updates, "created_at")
+	updates := map[string]interface{}{
+		"updated_at": time.Now(),
+	}
+	if req.Title != nil {
+		updates["title"] = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		updates["description"] = strings.TrimSpace(*req.Description)
+	}
+	if req.Price != nil {
+		updates["price"] = *req.Price
+	}
+	if req.Location != nil {
+		updates["location"] = strings.TrimSpace(*req.Location)
+	}
+	if req.Condition != nil {
+		updates["condition"] = *req.Condition
+	}
+	if req.Category != nil {
+		updates["category"] = *req.Category
+	}
+	if req.IsAvailable != nil {
+		// A sold item goes back on sale only when its transaction is cancelled
+		if *req.IsAvailable && items[0].BuyerID != nil {
+			return nil, fmt.Errorf("item already sold")
+		}
+		// Relisting counts toward the alumni listing cap
+		if *req.IsAvailable && !items[0].IsAvailable {
+			if err := checkAlumniListingLimit(ctx, sellerID); err != nil {
+				return nil, err
+			}
+		}
+		updates["is_available"] = *req.IsAvailable
+	}
 	
-	updates["updated_at"] = time.Now()
+	if req.Images != nil {
+		// Direct uploads can only be attached once they have been verified
+		if err := s.uploadService.CheckAttachable(req.Images); err != nil {
+			return nil, err
+		}
+		
+		// Images the item already had keep their IDs and captions
+		updates["images"] = req.Images
+		updates["image_details"] = mergeItemImages(ctx, &items[0], req.Images)
+	}
 	
 	var updatedItems []models.Item
-	_, _, err = client.From("items").
+	data, _, err = client.From("items").
 		Update(updates, "", "").
 		Eq("id", itemID).
 		Execute()
@@ -362,20 +481,27 @@ func (s *ItemService) UpdateItem(ctx context.Context, itemID, sellerID string, u
 		return nil, fmt.Errorf("failed to update item: %w", err)
 	}
 	
+	if err := json.Unmarshal(data, &updatedItems); err != nil {
+		return nil, fmt.Errorf("failed to parse updated item: %w", err)
+	}
+	
+	// Queue images copied from other sellers for moderation; this never blocks the edit
+	if err := s.imageModerationService.CheckItemImages(ctx, itemID, sellerID, req.Images); err != nil {
+		log.Printf("Failed to check item %s images for duplicates: %v", itemID, err)
+	}
+	
 	if len(updatedItems) == 0 {
 		return nil, fmt.Errorf("item not found")
 	}
 	
-	return &updatedItems[0], nil
+	item := &updatedItems[0]
+	item.ImageURLs = item.Images
+	return item, nil
 }
 
 // DeleteItem deletes an item (soft delete by changing status)
 func (s *ItemService) DeleteItem(ctx context.Context, itemID, sellerID string) error {
-	updates := map[string]interface{}{
-		"updated_at": time.Now(),
-	}
-	
-	_, err := s.UpdateItem(ctx, itemID, sellerID, updates)
+	_, err := s.UpdateItem(ctx, itemID, sellerID, &models.UpdateItemRequest{})
 	return err
 }
 
@@ -403,12 +529,13 @@ func (s *ItemService) GetItemsBySeller(ctx context.Context, sellerID string, lim
 	if len(items) > 0 && sellerID != "" {
 		var sellers []models.User
 		sellerData, _, err := client.From("user_profiles").
-			Select("id, nickname, name, avatar_url, rating", "exact", false).
+			Select("id, nickname, name, avatar_url, rating, profile_visibility", "exact", false).
 			Eq("id", sellerID).
 			Execute()
 		
 		if err == nil && len(sellerData) > 0 {
 			if err := json.Unmarshal(sellerData, &sellers); err == nil && len(sellers) > 0 {
+				RedactUser(&sellers[0])
 				// Attach seller info to all items
 				for i := range items {
 					items[i].Seller = &sellers[0]
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"pesxchange-backend/config"
+)
+
+// Reasons reported in RateLimitError
+const (
+	RateLimitNewConversations = "new_conversations"
+	RateLimitConversation     = "conversation"
+	RateLimitSlowMode         = "slow_mode"
+)
+
+// RateLimitError is returned when a messaging quota is exhausted
+type RateLimitError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded (%s), retry after %s", e.Reason, e.RetryAfter.Round(time.Second))
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds for the Retry-After header
+func (e *RateLimitError) RetryAfterSeconds() int {
+	seconds := int((e.RetryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// MessageLimiter enforces per-user messaging quotas with in-memory sliding windows
+type MessageLimiter struct {
+	newConversationsPerDay           int
+	newAccountNewConversationsPerDay int
+	messagesPerMinutePerConversation int
+	slowModeInterval                 time.Duration
+	newAccountAge                    time.Duration
+
+	mu      sync.Mutex
+	windows map[string][]time.Time
+}
+
+var (
+	defaultLimiter     *MessageLimiter
+	defaultLimiterOnce sync.Once
+)
+
+// GetMessageLimiter returns the process-wide limiter configured from the environment
+func GetMessageLimiter() *MessageLimiter {
+	defaultLimiterOnce.Do(func() {
+		cfg := config.Load()
+		defaultLimiter = NewMessageLimiter(cfg)
+		go defaultLimiter.cleanup(10 * time.Minute)
+	})
+	return defaultLimiter
+}
+
+// NewMessageLimiter creates a limiter with the quotas from cfg
+func NewMessageLimiter(cfg *config.Config) *MessageLimiter {
+	return &MessageLimiter{
+		newConversationsPerDay:           cfg.NewConversationsPerDay,
+		newAccountNewConversationsPerDay: cfg.NewAccountNewConversationsPerDay,
+		messagesPerMinutePerConversation: cfg.MessagesPerMinutePerConversation,
+		slowModeInterval:                 time.Duration(cfg.NewAccountSlowModeSeconds) * time.Second,
+		newAccountAge:                    time.Duration(cfg.NewAccountAgeHours) * time.Hour,
+		windows:                          make(map[string][]time.Time),
+	}
+}
+
+// MessageReservation is a message counted against a sender's quotas by Check, to be
+// released if sending it fails
+type MessageReservation struct {
+	keys []string
+	at   time.Time
+}
+
+// Check reports whether senderID may send a message to receiverID now and, if so, counts
+// the message against their quotas straight away so concurrent sends can't all pass.
+// accountCreatedAt decides whether slow mode applies; newConversation is true
+// when the two users have never exchanged a message.
+func (l *MessageLimiter) Check(senderID, receiverID string, accountCreatedAt time.Time, newConversation bool) (*MessageReservation, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	isNewAccount := now.Sub(accountCreatedAt) < l.newAccountAge
+
+	// Brand-new accounts must wait between messages, across all conversations
+	if isNewAccount && l.slowModeInterval > 0 {
+		if retry := l.retryAfter(slowModeKey(senderID), l.slowModeInterval, 1, now); retry > 0 {
+			return nil, &RateLimitError{Reason: RateLimitSlowMode, RetryAfter: retry}
+		}
+	}
+
+	if l.messagesPerMinutePerConversation > 0 {
+		if retry := l.retryAfter(conversationKey(senderID, receiverID), time.Minute, l.messagesPerMinutePerConversation, now); retry > 0 {
+			return nil, &RateLimitError{Reason: RateLimitConversation, RetryAfter: retry}
+		}
+	}
+
+	if newConversation {
+		max := l.newConversationsPerDay
+		if isNewAccount {
+			max = l.newAccountNewConversationsPerDay
+		}
+		if max > 0 {
+			if retry := l.retryAfter(newConversationKey(senderID), 24*time.Hour, max, now); retry > 0 {
+				return nil, &RateLimitError{Reason: RateLimitNewConversations, RetryAfter: retry}
+			}
+		}
+	}
+
+	reservation := &MessageReservation{
+		keys: []string{slowModeKey(senderID), conversationKey(senderID, receiverID)},
+		at:   now,
+	}
+	if newConversation {
+		reservation.keys = append(reservation.keys, newConversationKey(senderID))
+	}
+	// Slow mode only needs the latest message
+	l.windows[slowModeKey(senderID)] = []time.Time{now}
+	for _, key := range reservation.keys[1:] {
+		l.windows[key] = append(l.windows[key], now)
+	}
+
+	return reservation, nil
+}
+
+// Release gives back the quota taken by a reservation whose message failed to send
+func (l *MessageLimiter) Release(reservation *MessageReservation) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range reservation.keys {
+		events := l.windows[key]
+		for i, t := range events {
+			if t.Equal(reservation.at) {
+				l.windows[key] = append(events[:i], events[i+1:]...)
+				break
+			}
+		}
+		if len(l.windows[key]) == 0 {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// retryAfter prunes the window for key and returns how long until another event
+// is allowed, or zero if one is allowed now. Callers must hold l.mu.
+func (l *MessageLimiter) retryAfter(key string, window time.Duration, max int, now time.Time) time.Duration {
+	events := l.windows[key]
+
+	cutoff := now.Add(-window)
+	kept := events[:0]
+	for _, t := range events {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == 0 {
+		delete(l.windows, key)
+	} else {
+		l.windows[key] = kept
+	}
+
+	if len(kept) < max {
+		return 0
+	}
+
+	// The oldest event in the window has to expire before another is allowed
+	return kept[len(kept)-max].Add(window).Sub(now)
+}
+
+// cleanup periodically drops windows whose events have all expired
+func (l *MessageLimiter) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.mu.Lock()
+		cutoff := time.Now().Add(-24 * time.Hour)
+		for key, events := range l.windows {
+			if len(events) == 0 || events[len(events)-1].Before(cutoff) {
+				delete(l.windows, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+func slowModeKey(userID string) string {
+	return "slow:" + userID
+}
+
+func conversationKey(senderID, receiverID string) string {
+	return "conv:" + senderID + ":" + receiverID
+}
+
+func newConversationKey(userID string) string {
+	return "new:" + userID
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"pesxchange-backend/config"
+)
+
+// ScreeningAction is what happens to a message when a screening rule matches it
+type ScreeningAction string
+
+const (
+	ScreeningAllow ScreeningAction = "allow" // Record the hit only
+	ScreeningWarn  ScreeningAction = "warn"  // Deliver with a warning shown to the recipient
+	ScreeningMask  ScreeningAction = "mask"  // Deliver with the matched text hidden
+	ScreeningBlock ScreeningAction = "block" // Refuse to deliver
+)
+
+const (
+	screeningMaskText       = "[hidden]"
+	screeningReloadInterval = 30 * time.Second // How often the rules file is checked for changes
+)
+
+// severity orders actions so the strictest matching rule decides the outcome
+var screeningSeverity = map[ScreeningAction]int{
+	ScreeningAllow: 0,
+	ScreeningWarn:  1,
+	ScreeningMask:  2,
+	ScreeningBlock: 3,
+}
+
+// ScreeningRule is one entry of the message rules file
+type ScreeningRule struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`
+	Action       ScreeningAction `json:"action"`
+	Warning      string          `json:"warning,omitempty"`
+	Pattern      string          `json:"pattern,omitempty"`       // For "regex" rules
+	Phrases      []string        `json:"phrases,omitempty"`       // For "phrase" rules
+	AllowDomains []string        `json:"allow_domains,omitempty"` // For "url" rules
+}
+
+// ScreeningDetector finds the byte ranges of a message that a rule matches
+type ScreeningDetector interface {
+	FindAll(text string) [][]int
+}
+
+// ScreeningDetectorFactory builds a detector from a rule definition
+type ScreeningDetectorFactory func(rule ScreeningRule) (ScreeningDetector, error)
+
+var (
+	screeningDetectorsMu sync.RWMutex
+	screeningDetectors   = map[string]ScreeningDetectorFactory{
+		"phone":  newPhoneDetector,
+		"upi":    newUPIDetector,
+		"url":    newURLDetector,
+		"phrase": newPhraseDetector,
+		"regex":  newRegexDetector,
+	}
+)
+
+// RegisterScreeningDetector makes a new rule type available to the rules file
+func RegisterScreeningDetector(ruleType string, factory ScreeningDetectorFactory) {
+	screeningDetectorsMu.Lock()
+	defer screeningDetectorsMu.Unlock()
+	screeningDetectors[ruleType] = factory
+}
+
+// ScreeningMatch is a single rule match within a message
+type ScreeningMatch struct {
+	RuleID      string
+	Action      ScreeningAction
+	MatchedText string
+}
+
+// ScreeningResult is the outcome of running a message through the pipeline
+type ScreeningResult struct {
+	Action   ScreeningAction  // Strictest action among the matches
+	Text     string           // Message text after masking
+	Warnings []string         // Warnings for the recipient
+	Matches  []ScreeningMatch // Every rule hit, for moderation
+}
+
+type compiledRule struct {
+	rule     ScreeningRule
+	detector ScreeningDetector
+}
+
+// MessageScreener runs messages through the rules loaded from the rules file,
+// picking up edits to the file without a restart
+type MessageScreener struct {
+	path string
+
+	mu        sync.RWMutex
+	rules     []compiledRule
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+var (
+	defaultScreener     *MessageScreener
+	defaultScreenerOnce sync.Once
+)
+
+// GetMessageScreener returns the process-wide screener configured from MESSAGE_RULES_FILE
+func GetMessageScreener() *MessageScreener {
+	defaultScreenerOnce.Do(func() {
+		cfg := config.Load()
+		defaultScreener = NewMessageScreener(cfg.MessageRulesFile)
+	})
+	return defaultScreener
+}
+
+// NewMessageScreener creates a screener backed by the rules file at path
+func NewMessageScreener(path string) *MessageScreener {
+	s := &MessageScreener{path: path}
+	if err := s.reload(); err != nil {
+		log.Printf("Warning: message screening disabled, failed to load %s: %v", path, err)
+	}
+	s.lastCheck = time.Now()
+	return s
+}
+
+// Screen applies every rule to text and combines the results
+func (s *MessageScreener) Screen(text string) *ScreeningResult {
+	s.reloadIfChanged()
+
+	s.mu.RLock()
+	rules := s.rules
+	s.mu.RUnlock()
+
+	result := &ScreeningResult{
+		Action: ScreeningAllow,
+		Text:   text,
+	}
+
+	var maskRanges [][]int
+	for _, r := range rules {
+		ranges := r.detector.FindAll(text)
+		if len(ranges) == 0 {
+			continue
+		}
+
+		for _, rg := range ranges {
+			result.Matches = append(result.Matches, ScreeningMatch{
+				RuleID:      r.rule.ID,
+				Action:      r.rule.Action,
+				MatchedText: text[rg[0]:rg[1]],
+			})
+		}
+
+		switch r.rule.Action {
+		case ScreeningWarn:
+			if r.rule.Warning != "" {
+				result.Warnings = append(result.Warnings, r.rule.Warning)
+			}
+		case ScreeningMask:
+			maskRanges = append(maskRanges, ranges...)
+		}
+
+		if screeningSeverity[r.rule.Action] > screeningSeverity[result.Action] {
+			result.Action = r.rule.Action
+		}
+	}
+
+	if len(maskRanges) > 0 {
+		result.Text = maskText(text, maskRanges)
+	}
+
+	return result
+}
+
+// reloadIfChanged re-reads the rules file when its modification time changes
+func (s *MessageScreener) reloadIfChanged() {
+	s.mu.Lock()
+	if time.Since(s.lastCheck) < screeningReloadInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastCheck = time.Now()
+	modTime := s.modTime
+	s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil || info.ModTime().Equal(modTime) {
+		return
+	}
+
+	// Keep serving the previous rules if the new file is broken
+	if err := s.reload(); err != nil {
+		log.Printf("Warning: failed to reload message rules from %s: %v", s.path, err)
+	}
+}
+
+// reload parses and compiles the rules file
+func (s *MessageScreener) reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	var file struct {
+		Rules []ScreeningRule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("invalid rules file: %w", err)
+	}
+
+	screeningDetectorsMu.RLock()
+	defer screeningDetectorsMu.RUnlock()
+
+	compiled := make([]compiledRule, 0, len(file.Rules))
+	for _, rule := range file.Rules {
+		if _, ok := screeningSeverity[rule.Action]; !ok {
+			return fmt.Errorf("rule %s: unknown action %q", rule.ID, rule.Action)
+		}
+
+		factory, ok := screeningDetectors[rule.Type]
+		if !ok {
+			return fmt.Errorf("rule %s: unknown type %q", rule.ID, rule.Type)
+		}
+
+		detector, err := factory(rule)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+
+		compiled = append(compiled, compiledRule{rule: rule, detector: detector})
+	}
+
+	s.mu.Lock()
+	s.rules = compiled
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+
+	return nil
+}
+
+// maskText replaces each byte range with the mask text, merging overlaps
+func maskText(text string, ranges [][]int) string {
+	covered := make([]bool, len(text))
+	for _, rg := range ranges {
+		for i := rg[0]; i < rg[1]; i++ {
+			covered[i] = true
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if !covered[i] {
+			b.WriteByte(text[i])
+			continue
+		}
+		b.WriteString(screeningMaskText)
+		for i+1 < len(text) && covered[i+1] {
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// regexDetector matches a single regular expression
+type regexDetector struct {
+	re *regexp.Regexp
+}
+
+func (d *regexDetector) FindAll(text string) [][]int {
+	return d.re.FindAllStringIndex(text, -1)
+}
+
+func newRegexDetector(rule ScreeningRule) (ScreeningDetector, error) {
+	if rule.Pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return &regexDetector{re: re}, nil
+}
+
+// Indian mobile numbers, optionally prefixed with +91/0 and split by spaces or dashes
+var phonePattern = regexp.MustCompile(`(?:\+91[\s-]?|\b91[\s-]?|\b0|\b)[6-9](?:[\s-]?\d){9}\b`)
+
+func newPhoneDetector(rule ScreeningRule) (ScreeningDetector, error) {
+	return &regexDetector{re: phonePattern}, nil
+}
+
+// UPI virtual payment addresses look like name@bank, without the dotted domain of an email
+var upiPattern = regexp.MustCompile(`\b[a-zA-Z0-9.\-_]{2,256}@[a-zA-Z]{2,64}\b`)
+
+type upiDetector struct{}
+
+func (d *upiDetector) FindAll(text string) [][]int {
+	var matches [][]int
+	for _, rg := range upiPattern.FindAllStringIndex(text, -1) {
+		// Skip email addresses such as name@gmail.com
+		if rg[1] < len(text) && text[rg[1]] == '.' && rg[1]+1 < len(text) && isASCIILetter(text[rg[1]+1]) {
+			continue
+		}
+		matches = append(matches, rg)
+	}
+	return matches
+}
+
+func newUPIDetector(rule ScreeningRule) (ScreeningDetector, error) {
+	return &upiDetector{}, nil
+}
+
+// Links with a scheme, a www. prefix, or a bare domain on a common TLD
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://[^\s]+|www\.[^\s]+|[a-z0-9][a-z0-9-]*(?:\.[a-z0-9-]+)*\.(?:com|in|net|org|io|me|co|xyz|link|ly|app|site|online|info)\b(?:/[^\s]*)?)`)
+
+type urlDetector struct {
+	allowDomains []string
+}
+
+func (d *urlDetector) FindAll(text string) [][]int {
+	var matches [][]int
+	for _, rg := range urlPattern.FindAllStringIndex(text, -1) {
+		// Skip the domain part of email addresses
+		if rg[0] > 0 && text[rg[0]-1] == '@' {
+			continue
+		}
+		if d.isAllowed(text[rg[0]:rg[1]]) {
+			continue
+		}
+		matches = append(matches, rg)
+	}
+	return matches
+}
+
+func (d *urlDetector) isAllowed(link string) bool {
+	host := strings.ToLower(link)
+	host = strings.TrimPrefix(host, "http://")
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "www.")
+	if i := strings.IndexAny(host, "/?#:"); i >= 0 {
+		host = host[:i]
+	}
+
+	for _, allowed := range d.allowDomains {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func newURLDetector(rule ScreeningRule) (ScreeningDetector, error) {
+	return &urlDetector{allowDomains: rule.AllowDomains}, nil
+}
+
+// Known scam phrases, matched case-insensitively with flexible whitespace
+func newPhraseDetector(rule ScreeningRule) (ScreeningDetector, error) {
+	if len(rule.Phrases) == 0 {
+		return nil, fmt.Errorf("phrases are required")
+	}
+
+	alternatives := make([]string, 0, len(rule.Phrases))
+	for _, phrase := range rule.Phrases {
+		words := strings.Fields(phrase)
+		for i, w := range words {
+			words[i] = regexp.QuoteMeta(w)
+		}
+		alternatives = append(alternatives, strings.Join(words, `\s+`))
+	}
+
+	re, err := regexp.Compile(`(?i)\b(?:` + strings.Join(alternatives, "|") + `)\b`)
+	if err != nil {
+		return nil, err
+	}
+	return &regexDetector{re: re}, nil
+}
+
+func isASCIILetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+const (
+	maxSearchHits       = 50 // Hits returned without context
+	maxSearchHitsInCtx  = 20 // Hits returned when surrounding messages are requested
+	maxSearchContext    = 10 // Messages before/after a hit
+	searchSnippetRadius = 40 // Characters kept either side of the first match
+	minSearchQueryLen   = 2
+)
+
+var postgrestAscending = postgrest.OrderOpts{Ascending: true}
+
+// SearchMessages finds userID's messages containing query, grouped by conversation with the
+// most recent hits first. contextSize messages before and after each hit are included when > 0.
+func (s *MessageService) SearchMessages(ctx context.Context, userID, query string, contextSize int) ([]models.MessageSearchGroup, error) {
+	client := database.GetClient()
+
+	// PostgREST wildcards in user input would widen the match
+	query = strings.TrimSpace(strings.NewReplacer("%", "", "*", "").Replace(query))
+	if utf8.RuneCountInString(query) < minSearchQueryLen {
+		return nil, fmt.Errorf("search query too short")
+	}
+
+	if contextSize < 0 {
+		contextSize = 0
+	}
+	if contextSize > maxSearchContext {
+		contextSize = maxSearchContext
+	}
+
+	limit := maxSearchHits
+	if contextSize > 0 {
+		limit = maxSearchHitsInCtx
+	}
+
+	var messages []models.Message
+	data, _, err := client.From("messages").
+		Select("*", "exact", false).
+		Or(fmt.Sprintf("sender_id.eq.%s,receiver_id.eq.%s", userID, userID), "").
+		And(notDeletedForUser(userID), "").
+		Is("unsent_at", "null").
+		Ilike("message", fmt.Sprintf("%%%s%%", query)).
+		Order("created_at", nil).
+		Limit(limit, "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("failed to parse messages: %w", err)
+	}
+
+	blockedIDs, err := s.blockService.GetBlockedUserIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*models.MessageSearchGroup)
+	order := make([]string, 0)
+
+	for _, msg := range messages {
+		otherUserID := msg.SenderID
+		if msg.SenderID == userID {
+			otherUserID = msg.ReceiverID
+		}
+
+		if blockedIDs[otherUserID] {
+			continue
+		}
+
+		group, ok := groups[otherUserID]
+		if !ok {
+			group = &models.MessageSearchGroup{OtherUserID: otherUserID}
+			groups[otherUserID] = group
+			order = append(order, otherUserID)
+		}
+
+		snippet, parts := buildSnippet(msg.Message, query)
+		hit := models.MessageSearchHit{
+			Message:      msg,
+			Snippet:      snippet,
+			SnippetParts: parts,
+		}
+
+		if contextSize > 0 {
+			hit.Before, hit.After, err = s.getMessageContext(ctx, userID, &msg, contextSize)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		group.Hits = append(group.Hits, hit)
+	}
+
+	result := make([]models.MessageSearchGroup, 0, len(order))
+	for _, otherUserID := range order {
+		result = append(result, *groups[otherUserID])
+	}
+
+	s.attachSearchUsers(ctx, result)
+
+	return result, nil
+}
+
+// getMessageContext returns up to n messages either side of msg in the same conversation
+func (s *MessageService) getMessageContext(ctx context.Context, userID string, msg *models.Message, n int) ([]models.Message, []models.Message, error) {
+	client := database.GetClient()
+
+	conversation := fmt.Sprintf("and(sender_id.eq.%s,receiver_id.eq.%s),and(sender_id.eq.%s,receiver_id.eq.%s)", msg.SenderID, msg.ReceiverID, msg.ReceiverID, msg.SenderID)
+	createdAt := msg.CreatedAt.UTC().Format(time.RFC3339Nano)
+
+	var before []models.Message
+	data, _, err := client.From("messages").
+		Select("*", "exact", false).
+		Or(conversation, "").
+		And(notDeletedForUser(userID), "").
+		Lt("created_at", createdAt).
+		Order("created_at", nil).
+		Limit(n, "").
+		Execute()
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get message context: %w", err)
+	}
+	if err := json.Unmarshal(data, &before); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse message context: %w", err)
+	}
+
+	var after []models.Message
+	data, _, err = client.From("messages").
+		Select("*", "exact", false).
+		Or(conversation, "").
+		And(notDeletedForUser(userID), "").
+		Gt("created_at", createdAt).
+		Order("created_at", &postgrestAscending).
+		Limit(n, "").
+		Execute()
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get message context: %w", err)
+	}
+	if err := json.Unmarshal(data, &after); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse message context: %w", err)
+	}
+
+	// "before" was fetched newest first; return both oldest first
+	sort.Slice(before, func(i, j int) bool {
+		return before[i].CreatedAt.Before(before[j].CreatedAt)
+	})
+
+	return before, after, nil
+}
+
+// attachSearchUsers fills in a minimal profile for the other side of each conversation
+func (s *MessageService) attachSearchUsers(ctx context.Context, groups []models.MessageSearchGroup) {
+	if len(groups) == 0 {
+		return
+	}
+
+	client := database.GetClient()
+
+	ids := make([]string, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, g.OtherUserID)
+	}
+
+	var users []models.User
+	data, _, err := client.From("user_profiles").
+		Select("id, nickname, name, avatar_url, handle, profile_visibility", "exact", false).
+		In("id", ids).
+		Execute()
+
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &users); err != nil {
+		return
+	}
+
+	byID := make(map[string]*models.User, len(users))
+	for i := range users {
+		RedactUser(&users[i])
+		byID[users[i].ID] = &users[i]
+	}
+	for i := range groups {
+		groups[i].OtherUser = byID[groups[i].OtherUserID]
+	}
+}
+
+// buildSnippet cuts text down to a window around the first case-insensitive match of query
+// and splits it into matching and non-matching parts
+func buildSnippet(text, query string) (string, []models.SnippetPart) {
+	runes := []rune(text)
+	lowerRunes := []rune(strings.ToLower(text))
+	queryRunes := []rune(strings.ToLower(query))
+
+	// Lowercasing can change rune counts for a handful of scripts; fall back to no highlighting
+	if len(lowerRunes) != len(runes) {
+		return text, []models.SnippetPart{{Text: text}}
+	}
+
+	matches := findRuneMatches(lowerRunes, queryRunes)
+	if len(matches) == 0 {
+		return text, []models.SnippetPart{{Text: text}}
+	}
+
+	start := matches[0] - searchSnippetRadius
+	if start < 0 {
+		start = 0
+	}
+	end := matches[0] + len(queryRunes) + searchSnippetRadius
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var parts []models.SnippetPart
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "…"
+	}
+	if end < len(runes) {
+		suffix = "…"
+	}
+
+	pos := start
+	for _, m := range matches {
+		if m < start || m+len(queryRunes) > end {
+			continue
+		}
+		if m > pos {
+			parts = append(parts, models.SnippetPart{Text: string(runes[pos:m])})
+		}
+		parts = append(parts, models.SnippetPart{Text: string(runes[m : m+len(queryRunes)]), Match: true})
+		pos = m + len(queryRunes)
+	}
+	if pos < end {
+		parts = append(parts, models.SnippetPart{Text: string(runes[pos:end])})
+	}
+
+	if prefix != "" {
+		parts = append([]models.SnippetPart{{Text: prefix}}, parts...)
+	}
+	if suffix != "" {
+		parts = append(parts, models.SnippetPart{Text: suffix})
+	}
+
+	return prefix + string(runes[start:end]) + suffix, parts
+}
+
+// findRuneMatches returns the non-overlapping start offsets of needle in haystack
+func findRuneMatches(haystack, needle []rune) []int {
+	var matches []int
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		if string(haystack[i:i+len(needle)]) == string(needle) {
+			matches = append(matches, i)
+			i += len(needle) - 1
+		}
+	}
+	return matches
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"pesxchange-backend/database"
@@ -12,10 +14,23 @@ import (
 	"github.com/google/uuid"
 )
 
-type MessageService struct{}
+const (
+	messageEditWindow   = 15 * time.Minute // How long after sending a message can be edited
+	messageUnsendWindow = 24 * time.Hour   // How long after sending a message can be unsent for everyone
+)
+
+type MessageService struct {
+	blockService *BlockService
+	screener     *MessageScreener
+	limiter      *MessageLimiter
+}
 
 func NewMessageService() *MessageService {
-	return &MessageService{}
+	return &MessageService{
+		blockService: NewBlockService(),
+		screener:     GetMessageScreener(),
+		limiter:      GetMessageLimiter(),
+	}
 }
 
 // SendMessage sends a new message
@@ -27,13 +42,45 @@ func (s *MessageService) SendMessage(ctx context.Context, senderID string, req *
 		return nil, err
 	}
 	
+	// Refuse delivery if either side has blocked the other
+	blocked, err := s.blockService.IsBlocked(ctx, senderID, req.ReceiverID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, fmt.Errorf("user is blocked")
+	}
+	
+	// Enforce per-user messaging quotas
+	senderCreatedAt, err := s.getAccountCreatedAt(ctx, senderID)
+	if err != nil {
+		return nil, err
+	}
+	hasConversation, err := s.hasConversation(ctx, senderID, req.ReceiverID)
+	if err != nil {
+		return nil, err
+	}
+	reservation, err := s.limiter.Check(senderID, req.ReceiverID, senderCreatedAt, !hasConversation)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Screen for contact details, payment links and scam phrases
+	screening := s.screener.Screen(req.Message)
+	if screening.Action == ScreeningBlock {
+		// Blocked attempts still count, so blocked content can't be retried without limit
+		s.recordScreeningHits(ctx, nil, senderID, req.ReceiverID, screening)
+		return nil, fmt.Errorf("message blocked by screening")
+	}
+	
 	now := time.Now()
+	warning := strings.Join(screening.Warnings, " ")
 	
 	// Create message data map for insert
 	messageData := map[string]interface{}{
 		"sender_id":   senderID,
 		"receiver_id": req.ReceiverID,
-		"message":     req.Message,
+		"message":     screening.Text,
 		"is_read":     false,
 		"created_at":  now.Format(time.RFC3339),
 	}
@@ -43,18 +90,26 @@ func (s *MessageService) SendMessage(ctx context.Context, senderID string, req *
 		messageData["item_id"] = req.ItemID
 	}
 	
+	if warning != "" {
+		messageData["warning"] = warning
+	}
+	
 	data, _, err := client.From("messages").
 		Insert(messageData, false, "", "", "").
 		Execute()
 	
 	if err != nil {
+		s.limiter.Release(reservation)
 		return nil, fmt.Errorf("failed to send message: %w", err)
 	}
 	
+	s.sendAwayReply(ctx, senderID, req)
+	
 	// Parse the response data
 	if data != nil && len(data) > 0 {
 		var messages []models.Message
 		if err := json.Unmarshal(data, &messages); err == nil && len(messages) > 0 {
+			s.recordScreeningHits(ctx, &messages[0].ID, senderID, req.ReceiverID, screening)
 			return &messages[0], nil
 		}
 	}
@@ -75,24 +130,115 @@ func (s *MessageService) SendMessage(ctx context.Context, senderID string, req *
 		ID:         messageID,
 		SenderID:   senderID,
 		ReceiverID: req.ReceiverID,
-		Message:    req.Message,
+		Message:    screening.Text,
 		IsRead:     false,
 		CreatedAt:  now,
+		Warning:    warning,
 	}
 	if req.ItemID != "" {
 		message.ItemID = &req.ItemID
 	}
 	
+	s.recordScreeningHits(ctx, &message.ID, senderID, req.ReceiverID, screening)
+	
 	return message, nil
 }
 
+// recordScreeningHits stores screening matches for moderation. Failures are logged, not
+// returned, so a moderation outage never blocks messaging.
+func (s *MessageService) recordScreeningHits(ctx context.Context, messageID *string, senderID, receiverID string, result *ScreeningResult) {
+	if len(result.Matches) == 0 {
+		return
+	}
+	
+	client := database.GetClient()
+	
+	now := time.Now()
+	hits := make([]models.MessageScreeningHit, 0, len(result.Matches))
+	for _, match := range result.Matches {
+		hits = append(hits, models.MessageScreeningHit{
+			ID:          uuid.New().String(),
+			MessageID:   messageID,
+			SenderID:    senderID,
+			ReceiverID:  receiverID,
+			RuleID:      match.RuleID,
+			Action:      string(match.Action),
+			MatchedText: match.MatchedText,
+			CreatedAt:   now,
+		})
+	}
+	
+	_, _, err := client.From("message_screening_hits").
+		Insert(hits, false, "", "", "").
+		Execute()
+	
+	if err != nil {
+		log.Printf("Failed to record screening hits: %v", err)
+	}
+}
+
+// sendAwayReply answers a message to a user on vacation with their away message, once per
+// conversation per vacation. Failures are logged, not returned, since the message itself
+// was delivered.
+func (s *MessageService) sendAwayReply(ctx context.Context, senderID string, req *models.SendMessageRequest) {
+	vacation, err := getVacation(ctx, req.ReceiverID)
+	if err != nil || vacation == nil {
+		return
+	}
+	
+	client := database.GetClient()
+	
+	// Claim the conversation first so concurrent messages can't trigger two replies
+	_, _, err = client.From("vacation_auto_replies").
+		Insert(map[string]interface{}{
+			"user_id":       req.ReceiverID,
+			"other_user_id": senderID,
+		}, false, "", "", "").
+		Execute()
+	
+	if err != nil {
+		if !isUniqueViolation(err) {
+			log.Printf("Failed to record auto-reply to %s: %v", senderID, err)
+		}
+		return
+	}
+	
+	reply := map[string]interface{}{
+		"sender_id":   req.ReceiverID,
+		"receiver_id": senderID,
+		"message":     awayReplyText(vacation),
+		"is_read":     false,
+		"created_at":  time.Now().Format(time.RFC3339Nano), // Sorts after the message it answers
+	}
+	if req.ItemID != "" {
+		reply["item_id"] = req.ItemID
+	}
+	
+	_, _, err = client.From("messages").
+		Insert(reply, false, "", "", "").
+		Execute()
+	
+	if err != nil {
+		log.Printf("Failed to send auto-reply to %s: %v", senderID, err)
+	}
+}
+
 // GetMessages retrieves messages between two users for a specific item (or all messages if no item specified)
 func (s *MessageService) GetMessages(ctx context.Context, userID, otherUserID, itemID string, limit, offset int) ([]models.Message, error) {
 	client := database.GetClient()
 	
+	blocked, err := s.blockService.IsBlocked(ctx, userID, otherUserID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, fmt.Errorf("user is blocked")
+	}
+	
 	query := client.From("messages").
 		Select("*", "exact", false).
 		Or(fmt.Sprintf("and(sender_id.eq.%s,receiver_id.eq.%s),and(sender_id.eq.%s,receiver_id.eq.%s)", userID, otherUserID, otherUserID, userID), "").
+		And(notDeletedForUser(userID), "").
 		Order("created_at", nil)
 	
 	// Only filter by item_id if provided
@@ -116,14 +262,15 @@ func (s *MessageService) GetMessages(ctx context.Context, userID, otherUserID, i
 	return messages, nil
 }
 
-// GetActiveChats retrieves all active conversations for a user
-func (s *MessageService) GetActiveChats(ctx context.Context, userID string) ([]models.Chat, error) {
+// GetActiveChats retrieves the active (or, if archived is true, archived) conversations for a user
+func (s *MessageService) GetActiveChats(ctx context.Context, userID string, archived bool) ([]models.Chat, error) {
 	client := database.GetClient()
 	
 	// Get latest message for each conversation
 	data, _, err := client.From("messages").
 		Select("*", "exact", false).
 		Or(fmt.Sprintf("sender_id.eq.%s,receiver_id.eq.%s", userID, userID), "").
+		And(notDeletedForUser(userID), "").
 		Order("created_at", nil).
 		Execute()
 	
@@ -138,6 +285,17 @@ func (s *MessageService) GetActiveChats(ctx context.Context, userID string) ([]m
 		}
 	}
 	
+	// Conversations with blocked users are hidden from both sides
+	blockedIDs, err := s.blockService.GetBlockedUserIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	
+	settings, err := s.getConversationSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	
 	// Group messages by conversation (other_user + item)
 	chatMap := make(map[string]*models.Chat)
 	
@@ -153,10 +311,14 @@ func (s *MessageService) GetActiveChats(ctx context.Context, userID string) ([]m
 			otherUser = msg.Sender
 		}
 		
+		if blockedIDs[otherUserID] {
+			continue
+		}
+		
 		chatKey := fmt.Sprintf("%s-%s", userID, otherUserID)
 		
 		if _, exists := chatMap[chatKey]; !exists {
-			chatMap[chatKey] = &models.Chat{
+			chat := &models.Chat{
 				ID:          chatKey,
 				User1ID:     userID,
 				User2ID:     otherUserID,
@@ -165,12 +327,23 @@ func (s *MessageService) GetActiveChats(ctx context.Context, userID string) ([]m
 				OtherUser:   otherUser,
 				UnreadCount: 0, // TODO: Calculate unread count
 			}
+			
+			// An archived conversation comes back to the inbox when a newer message arrives
+			if setting, ok := settings[otherUserID]; ok {
+				chat.Muted = setting.Muted
+				chat.Archived = setting.ArchivedAt != nil && !msg.CreatedAt.After(*setting.ArchivedAt)
+			}
+			
+			chatMap[chatKey] = chat
 		}
 	}
 	
 	// Convert map to slice
 	chats := make([]models.Chat, 0, len(chatMap))
 	for _, chat := range chatMap {
+		if chat.Archived != archived {
+			continue
+		}
 		chats = append(chats, *chat)
 	}
 	
@@ -201,6 +374,304 @@ func (s *MessageService) MarkMessagesAsRead(ctx context.Context, userID, otherUs
 	return nil
 }
 
+// EditMessage replaces the text of a message sent by userID, keeping the previous text in message_edits
+func (s *MessageService) EditMessage(ctx context.Context, userID, messageID string, req *models.EditMessageRequest) (*models.Message, error) {
+	client := database.GetClient()
+	
+	message, err := s.getMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	
+	if message.SenderID != userID {
+		return nil, fmt.Errorf("unauthorized: not the message sender")
+	}
+	
+	if message.UnsentAt != nil {
+		return nil, fmt.Errorf("message has been unsent")
+	}
+	
+	if time.Since(message.CreatedAt) > messageEditWindow {
+		return nil, fmt.Errorf("edit window has expired")
+	}
+	
+	// Edits go through the same screening as new messages
+	screening := s.screener.Screen(req.Message)
+	s.recordScreeningHits(ctx, &message.ID, message.SenderID, message.ReceiverID, screening)
+	if screening.Action == ScreeningBlock {
+		return nil, fmt.Errorf("message blocked by screening")
+	}
+	
+	now := time.Now()
+	warning := strings.Join(screening.Warnings, " ")
+	
+	// Record the previous version before overwriting it
+	edit := &models.MessageEdit{
+		ID:              uuid.New().String(),
+		MessageID:       message.ID,
+		PreviousMessage: message.Message,
+		EditedAt:        now,
+	}
+	
+	_, _, err = client.From("message_edits").
+		Insert(edit, false, "", "", "").
+		Execute()
+	
+	if err != nil {
+		return nil, fmt.Errorf("failed to record message edit: %w", err)
+	}
+	
+	updates := map[string]interface{}{
+		"message":   screening.Text,
+		"warning":   warning,
+		"edited_at": now.Format(time.RFC3339),
+	}
+	
+	_, _, err = client.From("messages").
+		Update(updates, "", "").
+		Eq("id", message.ID).
+		Execute()
+	
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit message: %w", err)
+	}
+	
+	message.Message = screening.Text
+	message.Warning = warning
+	message.EditedAt = &now
+	
+	return message, nil
+}
+
+// GetMessageEdits returns the edit history of a message, newest first, to either participant
+func (s *MessageService) GetMessageEdits(ctx context.Context, userID, messageID string) ([]models.MessageEdit, error) {
+	client := database.GetClient()
+	
+	message, err := s.getMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	
+	if message.SenderID != userID && message.ReceiverID != userID {
+		return nil, fmt.Errorf("message not found")
+	}
+	
+	var edits []models.MessageEdit
+	data, _, err := client.From("message_edits").
+		Select("*", "exact", false).
+		Eq("message_id", messageID).
+		Order("edited_at", nil).
+		Execute()
+	
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message edits: %w", err)
+	}
+	
+	if err := json.Unmarshal(data, &edits); err != nil {
+		return nil, fmt.Errorf("failed to parse message edits: %w", err)
+	}
+	
+	return edits, nil
+}
+
+// DeleteMessage removes a message. With forEveryone the sender unsends it: the text and edit
+// history are wiped for both sides but the row stays so the thread remains coherent. Otherwise
+// the message is only hidden from userID.
+func (s *MessageService) DeleteMessage(ctx context.Context, userID, messageID string, forEveryone bool) error {
+	client := database.GetClient()
+	
+	message, err := s.getMessageByID(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	
+	if message.SenderID != userID && message.ReceiverID != userID {
+		return fmt.Errorf("message not found")
+	}
+	
+	updates := map[string]interface{}{}
+	
+	if forEveryone {
+		if message.SenderID != userID {
+			return fmt.Errorf("unauthorized: not the message sender")
+		}
+		if message.UnsentAt != nil {
+			return nil
+		}
+		if time.Since(message.CreatedAt) > messageUnsendWindow {
+			return fmt.Errorf("unsend window has expired")
+		}
+		
+		_, _, err = client.From("message_edits").
+			Delete("", "").
+			Eq("message_id", message.ID).
+			Execute()
+		
+		if err != nil {
+			return fmt.Errorf("failed to remove message edits: %w", err)
+		}
+		
+		updates["message"] = ""
+		updates["unsent_at"] = time.Now().Format(time.RFC3339)
+	} else if message.SenderID == userID {
+		updates["sender_deleted"] = true
+	} else {
+		updates["receiver_deleted"] = true
+	}
+	
+	_, _, err = client.From("messages").
+		Update(updates, "", "").
+		Eq("id", message.ID).
+		Execute()
+	
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	
+	return nil
+}
+
+// UpdateConversationSettings archives/unarchives or mutes/unmutes userID's conversation with otherUserID
+func (s *MessageService) UpdateConversationSettings(ctx context.Context, userID, otherUserID string, req *models.UpdateConversationRequest) (*models.ConversationSettings, error) {
+	client := database.GetClient()
+	
+	settings, err := s.getConversationSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	
+	setting, ok := settings[otherUserID]
+	if !ok {
+		setting = models.ConversationSettings{
+			UserID:      userID,
+			OtherUserID: otherUserID,
+		}
+	}
+	
+	now := time.Now()
+	
+	if req.Archived != nil {
+		if *req.Archived {
+			setting.ArchivedAt = &now
+		} else {
+			setting.ArchivedAt = nil
+		}
+	}
+	if req.Muted != nil {
+		setting.Muted = *req.Muted
+	}
+	setting.UpdatedAt = now
+	
+	_, _, err = client.From("conversation_settings").
+		Upsert(setting, "user_id,other_user_id", "", "").
+		Execute()
+	
+	if err != nil {
+		return nil, fmt.Errorf("failed to update conversation: %w", err)
+	}
+	
+	return &setting, nil
+}
+
+// getConversationSettings returns userID's conversation settings keyed by the other user's ID
+func (s *MessageService) getConversationSettings(ctx context.Context, userID string) (map[string]models.ConversationSettings, error) {
+	client := database.GetClient()
+	
+	var rows []models.ConversationSettings
+	data, _, err := client.From("conversation_settings").
+		Select("*", "exact", false).
+		Eq("user_id", userID).
+		Execute()
+	
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation settings: %w", err)
+	}
+	
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse conversation settings: %w", err)
+	}
+	
+	settings := make(map[string]models.ConversationSettings, len(rows))
+	for _, row := range rows {
+		settings[row.OtherUserID] = row
+	}
+	
+	return settings, nil
+}
+
+// getAccountCreatedAt returns when userID's profile was created
+func (s *MessageService) getAccountCreatedAt(ctx context.Context, userID string) (time.Time, error) {
+	client := database.GetClient()
+	
+	var users []models.User
+	data, _, err := client.From("user_profiles").
+		Select("id,created_at", "exact", false).
+		Eq("id", userID).
+		Execute()
+	
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get sender: %w", err)
+	}
+	
+	if err := json.Unmarshal(data, &users); err != nil || len(users) == 0 {
+		return time.Time{}, fmt.Errorf("sender not found")
+	}
+	
+	return users[0].CreatedAt, nil
+}
+
+// hasConversation reports whether the two users have exchanged any message before
+func (s *MessageService) hasConversation(ctx context.Context, userID, otherUserID string) (bool, error) {
+	client := database.GetClient()
+	
+	var messages []models.Message
+	data, _, err := client.From("messages").
+		Select("id", "exact", false).
+		Or(fmt.Sprintf("and(sender_id.eq.%s,receiver_id.eq.%s),and(sender_id.eq.%s,receiver_id.eq.%s)", userID, otherUserID, otherUserID, userID), "").
+		Limit(1, "").
+		Execute()
+	
+	if err != nil {
+		return false, fmt.Errorf("failed to check conversation: %w", err)
+	}
+	
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return false, fmt.Errorf("failed to parse conversation: %w", err)
+	}
+	
+	return len(messages) > 0, nil
+}
+
+// getMessageByID fetches a single message
+func (s *MessageService) getMessageByID(ctx context.Context, messageID string) (*models.Message, error) {
+	client := database.GetClient()
+	
+	var messages []models.Message
+	data, _, err := client.From("messages").
+		Select("*", "exact", false).
+		Eq("id", messageID).
+		Execute()
+	
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+	
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("message not found")
+	}
+	
+	return &messages[0], nil
+}
+
+// notDeletedForUser builds a filter excluding messages userID has deleted for themselves
+func notDeletedForUser(userID string) string {
+	return fmt.Sprintf("or(and(sender_id.eq.%s,sender_deleted.is.false),and(receiver_id.eq.%s,receiver_deleted.is.false))", userID, userID)
+}
+
 // validateMessageRequest validates the message request
 func (s *MessageService) validateMessageRequest(ctx context.Context, req *models.SendMessageRequest) error {
 	client := database.GetClient()
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// Notification types
+const (
+	NotificationNewListing = "new_listing" // A followed seller posted an item
+)
+
+const notificationBatchSize = 500 // Rows per insert when notifying many users at once
+
+type NotificationService struct{}
+
+func NewNotificationService() *NotificationService {
+	return &NotificationService{}
+}
+
+// Notify creates the same notification for each user in userIDs
+func (s *NotificationService) Notify(ctx context.Context, userIDs []string, notificationType string, actorID, itemID *string, message string) error {
+	client := database.GetClient()
+	now := time.Now()
+
+	for start := 0; start < len(userIDs); start += notificationBatchSize {
+		end := start + notificationBatchSize
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+
+		batch := make([]models.Notification, 0, end-start)
+		for _, userID := range userIDs[start:end] {
+			batch = append(batch, models.Notification{
+				ID:        uuid.New().String(),
+				UserID:    userID,
+				Type:      notificationType,
+				ActorID:   actorID,
+				ItemID:    itemID,
+				Message:   message,
+				CreatedAt: now,
+			})
+		}
+
+		_, _, err := client.From("notifications").
+			Insert(batch, false, "", "", "").
+			Execute()
+
+		if err != nil {
+			return fmt.Errorf("failed to create notifications: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetNotifications lists a user's notifications, newest first, along with their unread count
+func (s *NotificationService) GetNotifications(ctx context.Context, userID string, unreadOnly bool, limit, offset int) ([]models.Notification, int, error) {
+	client := database.GetClient()
+
+	query := client.From("notifications").
+		Select("*", "exact", false).
+		Eq("user_id", userID)
+	if unreadOnly {
+		query = query.Is("read_at", "null")
+	}
+
+	var notifications []models.Notification
+	data, _, err := query.
+		Order("created_at", nil).
+		Range(offset, offset+limit-1, "").
+		Execute()
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &notifications); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse notifications: %w", err)
+	}
+
+	_, unread, err := client.From("notifications").
+		Select("id", "exact", false).
+		Eq("user_id", userID).
+		Is("read_at", "null").
+		Limit(1, "").
+		Execute()
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	return notifications, int(unread), nil
+}
+
+// MarkRead marks a user's notifications as read: those in ids, or all of them if all is set
+func (s *NotificationService) MarkRead(ctx context.Context, userID string, ids []string, all bool) error {
+	if !all && len(ids) == 0 {
+		return nil
+	}
+
+	query := database.GetClient().From("notifications").
+		Update(map[string]interface{}{"read_at": time.Now()}, "", "").
+		Eq("user_id", userID).
+		Is("read_at", "null")
+	if !all {
+		query = query.In("id", ids)
+	}
+
+	if _, _, err := query.Execute(); err != nil {
+		return fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+
+	return nil
+}
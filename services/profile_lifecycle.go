@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"pesxchange-backend/config"
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// Programme lengths by the level code in an SRN: PES1UG20CS001 is an undergraduate admitted in 2020
+var (
+	programmeYears = map[string]int{"UG": 4, "PG": 2}
+	finalSemester  = map[string]int{"UG": 8, "PG": 4}
+)
+
+// Undergraduate programmes that run longer than four years, matched against the lowercased
+// programme name from PESU
+var fiveYearProgrammes = []string{"architecture", "b.arch", "barch", "llb", "integrated"}
+
+const graduationMonth = time.August // By August final results are out and the next batch has started
+
+var (
+	srnBatchPattern  = regexp.MustCompile(`^PES\d([A-Z]{2})(\d{2})[A-Z]{2}\d{3}$`)
+	semesterPattern  = regexp.MustCompile(`\d+`)
+	graduatedMarkers = []string{"graduat", "alumni", "passed out"}
+)
+
+// Profile statuses recorded in profile history
+const (
+	ProfileStatusStudent = "student"
+	ProfileStatusAlumni  = "alumni"
+)
+
+// AlumniListingLimitError is returned when an alumni account tries to list more items than allowed
+type AlumniListingLimitError struct {
+	Limit int
+}
+
+func (e *AlumniListingLimitError) Error() string {
+	return fmt.Sprintf("alumni listing limit reached: %d active listings allowed", e.Limit)
+}
+
+// IsGraduated reports whether PESU profile data shows a student has finished their programme:
+// either PESU marks them as graduated, or their batch's course has ended and they had reached
+// the final semester. Students held back a year are still in an earlier semester.
+func IsGraduated(profile *models.PESUProfile, now time.Time) bool {
+	semester := strings.ToLower(profile.Semester)
+	for _, marker := range graduatedMarkers {
+		if strings.Contains(semester, marker) {
+			return true
+		}
+	}
+
+	m := srnBatchPattern.FindStringSubmatch(strings.ToUpper(profile.SRN))
+	if m == nil {
+		return false
+	}
+	years, lastSemester, ok := programmeLength(m[1], profile.Program)
+	if !ok {
+		return false
+	}
+	admitted, _ := strconv.Atoi(m[2])
+
+	courseEnd := time.Date(2000+admitted+years, graduationMonth, 1, 0, 0, 0, 0, time.UTC)
+	if now.Before(courseEnd) {
+		return false
+	}
+
+	if n := semesterPattern.FindString(profile.Semester); n != "" {
+		current, _ := strconv.Atoi(n)
+		return current >= lastSemester
+	}
+	return true
+}
+
+// programmeLength returns how many years a programme runs and its final semester, from the
+// SRN's level code and the programme name
+func programmeLength(level, program string) (int, int, bool) {
+	years, ok := programmeYears[level]
+	if !ok {
+		return 0, 0, false
+	}
+	semesters := finalSemester[level]
+
+	if level == "UG" {
+		program = strings.ToLower(program)
+		for _, marker := range fiveYearProgrammes {
+			if strings.Contains(program, marker) {
+				return 5, 10, true
+			}
+		}
+	}
+
+	return years, semesters, true
+}
+
+// alumniSince works out a user's alumni date after a login: kept if they were already
+// alumni, now if they have just graduated and nil if they are (again) a student
+func alumniSince(existing *models.User, graduated bool, now time.Time) *time.Time {
+	if !graduated {
+		return nil
+	}
+	if existing != nil && existing.AlumniSince != nil {
+		return existing.AlumniSince
+	}
+	return &now
+}
+
+// getUserByPRN finds the account registered under a PRN, or nil if there is none. A PRN
+// shared by several accounts is ambiguous and never matched.
+func (s *UserService) getUserByPRN(ctx context.Context, prn string) (*models.User, error) {
+	if strings.TrimSpace(prn) == "" {
+		return nil, nil
+	}
+
+	var users []models.User
+	data, _, err := database.GetClient().From("user_profiles").
+		Select("*", "exact", false).
+		Eq("prn", prn).
+		Is("deleted_at", "null").
+		Limit(2, "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing user: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse existing user: %w", err)
+	}
+
+	if len(users) != 1 {
+		if len(users) > 1 {
+			log.Printf("Not linking SRN by PRN: PRN %s belongs to several accounts", prn)
+		}
+		return nil, nil
+	}
+
+	return &users[0], nil
+}
+
+// recordProfileChanges stores a history entry for each PESU-sourced field that differs
+// between before and after. Failures are logged, not returned, so auditing never blocks login.
+func (s *UserService) recordProfileChanges(ctx context.Context, before, after *models.User) {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"srn", before.SRN, after.SRN},
+		{"prn", before.PRN, after.PRN},
+		{"program", before.Program, after.Program},
+		{"branch", before.Branch, after.Branch},
+		{"semester", before.Semester, after.Semester},
+		{"section", before.Section, after.Section},
+		{"campus", before.Campus, after.Campus},
+		{"status", profileStatus(before), profileStatus(after)},
+	}
+
+	now := time.Now()
+	var entries []models.ProfileHistoryEntry
+	for _, f := range fields {
+		if f.old == f.new {
+			continue
+		}
+		entries = append(entries, models.ProfileHistoryEntry{
+			ID:        uuid.New().String(),
+			UserID:    after.ID,
+			Field:     f.name,
+			OldValue:  f.old,
+			NewValue:  f.new,
+			CreatedAt: now,
+		})
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	_, _, err := database.GetClient().From("user_profile_history").
+		Insert(entries, false, "", "", "").
+		Execute()
+
+	if err != nil {
+		log.Printf("Failed to record profile history for %s: %v", after.ID, err)
+	}
+}
+
+// GetProfileHistory lists changes to a user's PESU-sourced profile fields, newest first
+func (s *UserService) GetProfileHistory(ctx context.Context, userID string, limit, offset int) ([]models.ProfileHistoryEntry, int, error) {
+	var entries []models.ProfileHistoryEntry
+	data, total, err := database.GetClient().From("user_profile_history").
+		Select("*", "exact", false).
+		Eq("user_id", userID).
+		Order("created_at", nil).
+		Range(offset, offset+limit-1, "").
+		Execute()
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get profile history: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse profile history: %w", err)
+	}
+
+	return entries, int(total), nil
+}
+
+// checkAlumniListingLimit returns an *AlumniListingLimitError if sellerID is an alumni
+// account that already has as many items listed as alumni are allowed
+func checkAlumniListingLimit(ctx context.Context, sellerID string) error {
+	client := database.GetClient()
+
+	var users []models.User
+	data, _, err := client.From("user_profiles").
+		Select("id,alumni_since", "exact", false).
+		Eq("id", sellerID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to get seller: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &users); err != nil {
+		return fmt.Errorf("failed to parse seller: %w", err)
+	}
+
+	if len(users) == 0 || users[0].AlumniSince == nil {
+		return nil
+	}
+
+	limit := config.Load().AlumniMaxActiveListings
+	_, active, err := client.From("items").
+		Select("id", "exact", false).
+		Eq("seller_id", sellerID).
+		Eq("is_available", "true").
+		Limit(1, "").
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to count listings: %w", err)
+	}
+
+	if int(active) >= limit {
+		return &AlumniListingLimitError{Limit: limit}
+	}
+
+	return nil
+}
+
+func profileStatus(user *models.User) string {
+	if user.AlumniSince != nil {
+		return ProfileStatusAlumni
+	}
+	return ProfileStatusStudent
+}
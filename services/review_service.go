@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// Review roles: which side of the sale the reviewer was on
+const (
+	ReviewerRoleBuyer  = "buyer"
+	ReviewerRoleSeller = "seller"
+)
+
+// Ratings are a Bayesian average: every user starts with reviewPriorWeight imaginary reviews
+// of reviewPriorMean stars, so a single 5-star review doesn't put a new user above an
+// established seller
+const (
+	reviewPriorMean   = 3.5
+	reviewPriorWeight = 5
+)
+
+const reviewPageSize = 1000 // Rows fetched per request when recomputing ratings
+
+type ReviewService struct{}
+
+func NewReviewService() *ReviewService {
+	return &ReviewService{}
+}
+
+// CreateReview records reviewerID's review of the other party to a completed sale of an item
+// and updates the reviewee's rating. Each party can review a sale once.
+func (s *ReviewService) CreateReview(ctx context.Context, reviewerID string, req *models.CreateReviewRequest) (*models.Review, error) {
+	client := database.GetClient()
+
+	var items []models.Item
+	data, _, err := client.From("items").
+		Select("id", "exact", false).
+		Eq("id", req.ItemID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse item: %w", err)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("item not found")
+	}
+
+	// Only sales the buyer has confirmed can be reviewed
+	transaction, err := getCompletedTransaction(ctx, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction == nil {
+		return nil, fmt.Errorf("item not sold")
+	}
+
+	review := &models.Review{
+		ID:            uuid.New().String(),
+		ItemID:        req.ItemID,
+		ReviewerID:    reviewerID,
+		TransactionID: &transaction.ID,
+		Rating:        req.Rating,
+		Text:          strings.TrimSpace(req.Text),
+		CreatedAt:     time.Now(),
+	}
+	switch reviewerID {
+	case transaction.SellerID:
+		review.RevieweeID = transaction.BuyerID
+		review.ReviewerRole = ReviewerRoleSeller
+	case transaction.BuyerID:
+		review.RevieweeID = transaction.SellerID
+		review.ReviewerRole = ReviewerRoleBuyer
+	default:
+		return nil, fmt.Errorf("unauthorized: not a party to the sale")
+	}
+
+	_, _, err = client.From("reviews").
+		Insert(review, false, "", "", "").
+		Execute()
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("already reviewed")
+		}
+		return nil, fmt.Errorf("failed to create review: %w", err)
+	}
+
+	if err := s.UpdateRating(ctx, review.RevieweeID); err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// ReplyToReview adds the seller's public reply to a review a buyer left them. Each review
+// can be replied to once.
+func (s *ReviewService) ReplyToReview(ctx context.Context, reviewID, userID, reply string) (*models.Review, error) {
+	client := database.GetClient()
+
+	review, err := s.getReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	if review.RevieweeID != userID || review.ReviewerRole != ReviewerRoleBuyer {
+		return nil, fmt.Errorf("unauthorized: not the reviewed seller")
+	}
+	if review.Reply != nil {
+		return nil, fmt.Errorf("already replied")
+	}
+
+	var updated []models.Review
+	data, _, err := client.From("reviews").
+		Update(map[string]interface{}{
+			"reply":      strings.TrimSpace(reply),
+			"replied_at": time.Now(),
+		}, "", "").
+		Eq("id", reviewID).
+		Is("reply", "null").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to reply to review: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return nil, fmt.Errorf("failed to parse review: %w", err)
+	}
+
+	if len(updated) == 0 {
+		return nil, fmt.Errorf("already replied")
+	}
+
+	return &updated[0], nil
+}
+
+// GetUserReviews returns the reviews a user has received, newest first, with each
+// reviewer's display profile
+func (s *ReviewService) GetUserReviews(ctx context.Context, userID string, limit, offset int) ([]models.Review, int, error) {
+	client := database.GetClient()
+
+	var reviews []models.Review
+	data, total, err := client.From("reviews").
+		Select("*", "exact", false).
+		Eq("reviewee_id", userID).
+		Order("created_at", nil).
+		Range(offset, offset+limit-1, "").
+		Execute()
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get reviews: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &reviews); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse reviews: %w", err)
+	}
+
+	if len(reviews) == 0 {
+		return []models.Review{}, int(total), nil
+	}
+
+	ids := make([]string, 0, len(reviews))
+	for _, r := range reviews {
+		ids = append(ids, r.ReviewerID)
+	}
+
+	var users []models.User
+	userData, _, err := client.From("user_profiles").
+		Select("id, nickname, name, avatar_url, handle, profile_visibility", "exact", false).
+		In("id", ids).
+		Execute()
+
+	if err == nil {
+		if err := json.Unmarshal(userData, &users); err == nil {
+			byID := make(map[string]*models.User, len(users))
+			for i := range users {
+				RedactUser(&users[i])
+				byID[users[i].ID] = &users[i]
+			}
+			for i := range reviews {
+				reviews[i].Reviewer = byID[reviews[i].ReviewerID]
+			}
+		}
+	}
+
+	return reviews, int(total), nil
+}
+
+// UpdateRating recomputes a user's rating and review count from their reviews
+func (s *ReviewService) UpdateRating(ctx context.Context, userID string) error {
+	client := database.GetClient()
+
+	// Pages are ordered by ID so they can't overlap or skip rows
+	sum, count := 0, 0
+	for offset := 0; ; offset += reviewPageSize {
+		var reviews []models.Review
+		data, _, err := client.From("reviews").
+			Select("rating", "exact", false).
+			Eq("reviewee_id", userID).
+			Order("id", &postgrestAscending).
+			Range(offset, offset+reviewPageSize-1, "").
+			Execute()
+
+		if err != nil {
+			return fmt.Errorf("failed to get reviews: %w", err)
+		}
+
+		if err := json.Unmarshal(data, &reviews); err != nil {
+			return fmt.Errorf("failed to parse reviews: %w", err)
+		}
+
+		for _, r := range reviews {
+			sum += r.Rating
+		}
+		count += len(reviews)
+
+		if len(reviews) < reviewPageSize {
+			break
+		}
+	}
+
+	_, _, err := client.From("user_profiles").
+		Update(map[string]interface{}{
+			"rating":       BayesianRating(sum, count),
+			"review_count": count,
+		}, "", "").
+		Eq("id", userID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to update rating: %w", err)
+	}
+
+	return nil
+}
+
+// BayesianRating averages count ratings totalling sum with the prior, rounded to two
+// decimal places. Users without reviews have a rating of 0.
+func BayesianRating(sum, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	rating := (reviewPriorMean*reviewPriorWeight + float64(sum)) / float64(reviewPriorWeight+count)
+	return math.Round(rating*100) / 100
+}
+
+func (s *ReviewService) getReview(ctx context.Context, reviewID string) (*models.Review, error) {
+	var reviews []models.Review
+	data, _, err := database.GetClient().From("reviews").
+		Select("*", "exact", false).
+		Eq("id", reviewID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &reviews); err != nil {
+		return nil, fmt.Errorf("failed to parse review: %w", err)
+	}
+
+	if len(reviews) == 0 {
+		return nil, fmt.Errorf("review not found")
+	}
+
+	return &reviews[0], nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"pesxchange-backend/config"
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+)
+
+const storageUsagePageSize = 1000 // PostgREST's default max rows per request
+
+// Storage quota tiers
+const (
+	StorageTierStandard = "standard"
+	StorageTierVerified = "verified"
+	StorageTierTrusted  = "trusted"
+)
+
+// Resources reported in StorageQuotaError
+const (
+	QuotaResourceBytes  = "bytes"
+	QuotaResourceImages = "images"
+)
+
+// StorageQuotaError is returned when an upload would take a user over their storage quota
+type StorageQuotaError struct {
+	Resource string
+	Used     int64
+	Limit    int64
+}
+
+func (e *StorageQuotaError) Error() string {
+	return fmt.Sprintf("storage quota exceeded (%s): %d of %d used", e.Resource, e.Used, e.Limit)
+}
+
+// GetStorageUsage totals a user's completed uploads and unexpired upload intents against
+// the quota for their seller tier
+func (s *UploadService) GetStorageUsage(ctx context.Context, userID string) (*models.StorageUsage, error) {
+	client := database.GetClient()
+
+	var users []models.User
+	data, _, err := client.From("user_profiles").
+		Select("verified,trusted_seller", "exact", false).
+		Eq("id", userID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse user: %w", err)
+	}
+
+	if len(users) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	usage := storageQuota(config.Load(), &users[0])
+
+	now := time.Now()
+	for offset := 0; ; offset += storageUsagePageSize {
+		var uploads []models.Upload
+		data, _, err := client.From("uploads").
+			Select("status,size,stored_bytes,expires_at", "exact", false).
+			Eq("user_id", userID).
+			Not("status", "eq", UploadStatusFailed).
+			Order("created_at", &postgrestAscending).
+			Range(offset, offset+storageUsagePageSize-1, "").
+			Execute()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get uploads: %w", err)
+		}
+
+		if err := json.Unmarshal(data, &uploads); err != nil {
+			return nil, fmt.Errorf("failed to parse uploads: %w", err)
+		}
+
+		for _, upload := range uploads {
+			switch {
+			case upload.Status == UploadStatusCompleted && upload.StoredBytes > 0:
+				usage.Bytes += upload.StoredBytes
+			case upload.Status == UploadStatusCompleted:
+				usage.Bytes += upload.Size // Recorded before stored bytes were tracked
+			case upload.ExpiresAt.After(now):
+				usage.Bytes += upload.Size // Pending intents reserve their declared size
+			default:
+				continue // Expired intent; garbage collection removes it
+			}
+			usage.Images++
+		}
+
+		if len(uploads) < storageUsagePageSize {
+			break
+		}
+	}
+
+	return usage, nil
+}
+
+// CheckQuota returns a *StorageQuotaError if adding images totalling bytes would take the
+// user over either of their limits
+func (s *UploadService) CheckQuota(ctx context.Context, userID string, bytes int64, images int) error {
+	usage, err := s.GetStorageUsage(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if usage.ImagesLimit > 0 && usage.Images+images > usage.ImagesLimit {
+		return &StorageQuotaError{Resource: QuotaResourceImages, Used: int64(usage.Images), Limit: int64(usage.ImagesLimit)}
+	}
+	if usage.BytesLimit > 0 && usage.Bytes+bytes > usage.BytesLimit {
+		return &StorageQuotaError{Resource: QuotaResourceBytes, Used: usage.Bytes, Limit: usage.BytesLimit}
+	}
+
+	return nil
+}
+
+// SetTrustedSeller marks a user as a trusted seller, raising their storage quota
+func (s *UploadService) SetTrustedSeller(ctx context.Context, userID string, trusted bool) error {
+	data, _, err := database.GetClient().From("user_profiles").
+		Update(map[string]interface{}{"trusted_seller": trusted}, "", "").
+		Eq("id", userID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	var users []models.User
+	if err := json.Unmarshal(data, &users); err != nil || len(users) == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// storageQuota returns an empty usage report carrying the tier and limits for user
+func storageQuota(cfg *config.Config, user *models.User) *models.StorageUsage {
+	usage := &models.StorageUsage{
+		Tier:        StorageTierStandard,
+		BytesLimit:  int64(cfg.StorageQuotaMB) * 1024 * 1024,
+		ImagesLimit: cfg.StorageQuotaImages,
+	}
+
+	switch {
+	case user.TrustedSeller:
+		usage.Tier = StorageTierTrusted
+		usage.BytesLimit = int64(cfg.TrustedStorageQuotaMB) * 1024 * 1024
+		usage.ImagesLimit = cfg.TrustedStorageQuotaImages
+	case user.Verified:
+		usage.Tier = StorageTierVerified
+		usage.BytesLimit = int64(cfg.VerifiedStorageQuotaMB) * 1024 * 1024
+		usage.ImagesLimit = cfg.VerifiedStorageQuotaImages
+	}
+
+	return usage
+}
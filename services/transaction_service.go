@@ -0,0 +1,527 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// Transaction statuses
+const (
+	TransactionPending   = "pending"   // Marked sold by the seller, awaiting the buyer's confirmation
+	TransactionCompleted = "completed" // Confirmed by the buyer
+	TransactionCancelled = "cancelled" // Called off before confirmation; the item is back on sale
+	TransactionDisputed  = "disputed"  // One side reported a problem
+)
+
+// Transaction roles for listing history
+const (
+	TransactionRoleBuyer  = "buyer"
+	TransactionRoleSeller = "seller"
+)
+
+const maxBuyerCandidateMessages = 1000 // Recent messages scanned for conversation partners
+
+type TransactionService struct {
+	blockService *BlockService
+}
+
+func NewTransactionService() *TransactionService {
+	return &TransactionService{
+		blockService: NewBlockService(),
+	}
+}
+
+// CreateTransaction marks an item owned by sellerID as sold to buyer, who must be someone the
+// seller has messaged. The item leaves the market until the sale is cancelled.
+func (s *TransactionService) CreateTransaction(ctx context.Context, itemID, sellerID string, req *models.MarkItemSoldRequest) (*models.Transaction, error) {
+	client := database.GetClient()
+
+	var items []models.Item
+	data, _, err := client.From("items").
+		Select("id,seller_id,price,buyer_id", "exact", false).
+		Eq("id", itemID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse item: %w", err)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("item not found")
+	}
+
+	item := &items[0]
+	if item.SellerID != sellerID {
+		return nil, fmt.Errorf("unauthorized: not the item owner")
+	}
+	if item.BuyerID != nil {
+		return nil, fmt.Errorf("item already sold")
+	}
+	if req.BuyerID == sellerID {
+		return nil, fmt.Errorf("invalid buyer")
+	}
+
+	partners, err := s.conversationPartners(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := partners[req.BuyerID]; !ok {
+		return nil, fmt.Errorf("invalid buyer")
+	}
+
+	blocked, err := s.blockService.IsBlocked(ctx, sellerID, req.BuyerID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, fmt.Errorf("invalid buyer")
+	}
+
+	now := time.Now()
+	transaction := &models.Transaction{
+		ID:         uuid.New().String(),
+		ItemID:     itemID,
+		SellerID:   sellerID,
+		BuyerID:    req.BuyerID,
+		Status:     TransactionPending,
+		FinalPrice: item.Price,
+		HandoverAt: req.HandoverAt,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if req.FinalPrice != nil {
+		transaction.FinalPrice = *req.FinalPrice
+	}
+
+	// The partial unique index on item_id settles races between two sales of one item
+	_, _, err = client.From("transactions").
+		Insert(transaction, false, "", "", "").
+		Execute()
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("item already sold")
+		}
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	_, _, err = client.From("items").
+		Update(map[string]interface{}{
+			"buyer_id":     req.BuyerID,
+			"sold_at":      now,
+			"is_available": false,
+			"updated_at":   now,
+		}, "", "").
+		Eq("id", itemID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark item sold: %w", err)
+	}
+
+	return transaction, nil
+}
+
+// ConfirmTransaction records the buyer's confirmation of a pending sale
+func (s *TransactionService) ConfirmTransaction(ctx context.Context, transactionID, userID string) (*models.Transaction, error) {
+	transaction, err := s.getTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.BuyerID != userID {
+		return nil, fmt.Errorf("unauthorized: only the buyer can confirm")
+	}
+	if transaction.Status != TransactionPending {
+		return nil, fmt.Errorf("transaction not pending")
+	}
+
+	now := time.Now()
+	return s.updateStatus(ctx, transaction, TransactionPending, map[string]interface{}{
+		"status":       TransactionCompleted,
+		"confirmed_at": now,
+		"updated_at":   now,
+	})
+}
+
+// CancelTransaction calls off a pending sale and puts the item back on the market. Either
+// party can cancel until the buyer confirms; after that, problems go through a dispute.
+func (s *TransactionService) CancelTransaction(ctx context.Context, transactionID, userID string) (*models.Transaction, error) {
+	client := database.GetClient()
+
+	transaction, err := s.getTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isTransactionParty(transaction, userID) {
+		return nil, fmt.Errorf("unauthorized: not a party to the transaction")
+	}
+	if transaction.Status != TransactionPending {
+		return nil, fmt.Errorf("transaction not pending")
+	}
+
+	now := time.Now()
+	cancelled, err := s.updateStatus(ctx, transaction, TransactionPending, map[string]interface{}{
+		"status":       TransactionCancelled,
+		"cancelled_at": now,
+		"cancelled_by": userID,
+		"updated_at":   now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, err = client.From("items").
+		Update(map[string]interface{}{
+			"buyer_id":     nil,
+			"sold_at":      nil,
+			"is_available": true,
+			"updated_at":   now,
+		}, "", "").
+		Eq("id", transaction.ItemID).
+		Eq("buyer_id", transaction.BuyerID).
+		Execute()
+
+	if err != nil {
+		log.Printf("Failed to relist item %s after cancelling transaction %s: %v", transaction.ItemID, transaction.ID, err)
+	}
+
+	return cancelled, nil
+}
+
+// DisputeTransaction flags a pending or completed sale for moderators. The item stays off
+// the market while the dispute is open.
+func (s *TransactionService) DisputeTransaction(ctx context.Context, transactionID, userID, reason string) (*models.Transaction, error) {
+	transaction, err := s.getTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isTransactionParty(transaction, userID) {
+		return nil, fmt.Errorf("unauthorized: not a party to the transaction")
+	}
+	if transaction.Status != TransactionPending && transaction.Status != TransactionCompleted {
+		return nil, fmt.Errorf("transaction not disputable")
+	}
+
+	now := time.Now()
+	return s.updateStatus(ctx, transaction, transaction.Status, map[string]interface{}{
+		"status":         TransactionDisputed,
+		"disputed_at":    now,
+		"disputed_by":    userID,
+		"dispute_reason": strings.TrimSpace(reason),
+		"updated_at":     now,
+	})
+}
+
+// GetTransaction returns a transaction to one of its parties, with the item and counterpart
+func (s *TransactionService) GetTransaction(ctx context.Context, transactionID, userID string) (*models.Transaction, error) {
+	transaction, err := s.getTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isTransactionParty(transaction, userID) {
+		return nil, fmt.Errorf("unauthorized: not a party to the transaction")
+	}
+
+	transactions := []models.Transaction{*transaction}
+	s.attachDetails(ctx, userID, transactions)
+	return &transactions[0], nil
+}
+
+// GetUserTransactions lists a user's purchases and sales, newest first. role ("buyer" or
+// "seller") and status narrow the list when set.
+func (s *TransactionService) GetUserTransactions(ctx context.Context, userID, role, status string, limit, offset int) ([]models.Transaction, int, error) {
+	query := database.GetClient().From("transactions").
+		Select("*", "exact", false)
+
+	switch role {
+	case TransactionRoleBuyer:
+		query = query.Eq("buyer_id", userID)
+	case TransactionRoleSeller:
+		query = query.Eq("seller_id", userID)
+	default:
+		query = query.Or(fmt.Sprintf("buyer_id.eq.%s,seller_id.eq.%s", userID, userID), "")
+	}
+	if status != "" {
+		query = query.Eq("status", status)
+	}
+
+	var transactions []models.Transaction
+	data, total, err := query.
+		Order("created_at", nil).
+		Range(offset, offset+limit-1, "").
+		Execute()
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &transactions); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse transactions: %w", err)
+	}
+
+	if len(transactions) == 0 {
+		return []models.Transaction{}, int(total), nil
+	}
+
+	s.attachDetails(ctx, userID, transactions)
+	return transactions, int(total), nil
+}
+
+// GetBuyerCandidates lists who a seller can mark an item sold to: the people they have
+// messaged, those who asked about the item first
+func (s *TransactionService) GetBuyerCandidates(ctx context.Context, itemID, sellerID string) ([]models.User, error) {
+	client := database.GetClient()
+
+	var items []models.Item
+	data, _, err := client.From("items").
+		Select("id,seller_id", "exact", false).
+		Eq("id", itemID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse item: %w", err)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("item not found")
+	}
+	if items[0].SellerID != sellerID {
+		return nil, fmt.Errorf("unauthorized: not the item owner")
+	}
+
+	partners, err := s.conversationPartners(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	blockedIDs, err := s.blockService.GetBlockedUserIDs(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(partners))
+	for id := range partners {
+		if !blockedIDs[id] {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return []models.User{}, nil
+	}
+
+	var users []models.User
+	data, _, err = client.From("user_profiles").
+		Select("id, nickname, name, avatar_url, handle, profile_visibility", "exact", false).
+		In("id", ids).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse users: %w", err)
+	}
+
+	// People who messaged about this item first, then everyone else
+	asked := make([]models.User, 0, len(users))
+	others := make([]models.User, 0, len(users))
+	for i := range users {
+		RedactUser(&users[i])
+		if partners[users[i].ID][itemID] {
+			asked = append(asked, users[i])
+		} else {
+			others = append(others, users[i])
+		}
+	}
+
+	return append(asked, others...), nil
+}
+
+// conversationPartners maps each user sellerID has exchanged recent messages with to the
+// items they talked about
+func (s *TransactionService) conversationPartners(ctx context.Context, userID string) (map[string]map[string]bool, error) {
+	var messages []models.Message
+	data, _, err := database.GetClient().From("messages").
+		Select("sender_id,receiver_id,item_id", "exact", false).
+		Or(fmt.Sprintf("sender_id.eq.%s,receiver_id.eq.%s", userID, userID), "").
+		Order("created_at", nil).
+		Limit(maxBuyerCandidateMessages, "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("failed to parse messages: %w", err)
+	}
+
+	partners := make(map[string]map[string]bool)
+	for _, msg := range messages {
+		otherID := msg.SenderID
+		if otherID == userID {
+			otherID = msg.ReceiverID
+		}
+		if partners[otherID] == nil {
+			partners[otherID] = make(map[string]bool)
+		}
+		if msg.ItemID != nil {
+			partners[otherID][*msg.ItemID] = true
+		}
+	}
+
+	return partners, nil
+}
+
+// attachDetails joins each transaction's item and the counterpart of userID
+func (s *TransactionService) attachDetails(ctx context.Context, userID string, transactions []models.Transaction) {
+	client := database.GetClient()
+
+	itemIDs := make([]string, 0, len(transactions))
+	userIDs := make([]string, 0, len(transactions))
+	for _, t := range transactions {
+		itemIDs = append(itemIDs, t.ItemID)
+		userIDs = append(userIDs, transactionCounterpart(&t, userID))
+	}
+
+	var items []models.Item
+	data, _, err := client.From("items").
+		Select("id,title,price,image_details,is_available", "exact", false).
+		In("id", itemIDs).
+		Execute()
+
+	if err == nil {
+		if err := json.Unmarshal(data, &items); err == nil {
+			byID := make(map[string]*models.Item, len(items))
+			for i := range items {
+				byID[items[i].ID] = &items[i]
+			}
+			for i := range transactions {
+				transactions[i].Item = byID[transactions[i].ItemID]
+			}
+		}
+	}
+
+	var users []models.User
+	data, _, err = client.From("user_profiles").
+		Select("id, nickname, name, avatar_url, handle, profile_visibility", "exact", false).
+		In("id", userIDs).
+		Execute()
+
+	if err == nil {
+		if err := json.Unmarshal(data, &users); err == nil {
+			byID := make(map[string]*models.User, len(users))
+			for i := range users {
+				RedactUser(&users[i])
+				byID[users[i].ID] = &users[i]
+			}
+			for i := range transactions {
+				transactions[i].Other = byID[transactionCounterpart(&transactions[i], userID)]
+			}
+		}
+	}
+}
+
+// updateStatus applies updates if the transaction is still in fromStatus
+func (s *TransactionService) updateStatus(ctx context.Context, transaction *models.Transaction, fromStatus string, updates map[string]interface{}) (*models.Transaction, error) {
+	var updated []models.Transaction
+	data, _, err := database.GetClient().From("transactions").
+		Update(updates, "", "").
+		Eq("id", transaction.ID).
+		Eq("status", fromStatus).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update transaction: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction: %w", err)
+	}
+
+	// Someone else changed the status first
+	if len(updated) == 0 {
+		return nil, fmt.Errorf("transaction not pending")
+	}
+
+	return &updated[0], nil
+}
+
+func (s *TransactionService) getTransaction(ctx context.Context, transactionID string) (*models.Transaction, error) {
+	var transactions []models.Transaction
+	data, _, err := database.GetClient().From("transactions").
+		Select("*", "exact", false).
+		Eq("id", transactionID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction: %w", err)
+	}
+
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("transaction not found")
+	}
+
+	return &transactions[0], nil
+}
+
+// getCompletedTransaction returns the completed sale of an item, if there is one
+func getCompletedTransaction(ctx context.Context, itemID string) (*models.Transaction, error) {
+	var transactions []models.Transaction
+	data, _, err := database.GetClient().From("transactions").
+		Select("*", "exact", false).
+		Eq("item_id", itemID).
+		Eq("status", TransactionCompleted).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction: %w", err)
+	}
+
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+
+	return &transactions[0], nil
+}
+
+func isTransactionParty(transaction *models.Transaction, userID string) bool {
+	return transaction.BuyerID == userID || transaction.SellerID == userID
+}
+
+func transactionCounterpart(transaction *models.Transaction, userID string) string {
+	if transaction.SellerID == userID {
+		return transaction.BuyerID
+	}
+	return transaction.SellerID
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+	"pesxchange-backend/storage"
+	"pesxchange-backend/utils"
+)
+
+const (
+	uploadGCBatchSize = 200
+	MinUploadGCGrace  = time.Hour // Shorter grace periods would race uploads still being attached
+)
+
+// CollectGarbage deletes uploads older than grace that no item references: completed images
+// that were never attached (or were removed from their item) and direct uploads that were
+// never completed. With dryRun set it only reports what would be deleted.
+func (s *UploadService) CollectGarbage(ctx context.Context, grace time.Duration, dryRun bool) (*models.UploadGCReport, error) {
+	client := database.GetClient()
+
+	if grace < MinUploadGCGrace {
+		grace = MinUploadGCGrace
+	}
+
+	report := &models.UploadGCReport{
+		DryRun:      dryRun,
+		GracePeriod: grace.String(),
+		Orphans:     []models.OrphanedUpload{},
+		Failures:    []string{},
+	}
+	cutoff := time.Now().Add(-grace).UTC().Format(time.RFC3339Nano)
+
+	offset := 0
+	for {
+		var uploads []models.Upload
+		data, _, err := client.From("uploads").
+			Select("*", "exact", false).
+			Lt("created_at", cutoff).
+			Order("created_at", &postgrestAscending).
+			Range(offset, offset+uploadGCBatchSize-1, "").
+			Execute()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get uploads: %w", err)
+		}
+
+		if err := json.Unmarshal(data, &uploads); err != nil {
+			return nil, fmt.Errorf("failed to parse uploads: %w", err)
+		}
+
+		deleted := 0
+		for _, upload := range uploads {
+			report.Scanned++
+
+			orphan, err := s.findOrphan(ctx, &upload)
+			if err != nil {
+				report.Failures = append(report.Failures, fmt.Sprintf("%s: %s", upload.ID, err.Error()))
+				continue
+			}
+			if orphan == nil {
+				continue
+			}
+			report.Orphans = append(report.Orphans, *orphan)
+
+			if dryRun {
+				continue
+			}
+
+			if err := s.deleteOrphan(ctx, orphan); err != nil {
+				report.Failures = append(report.Failures, fmt.Sprintf("%s: %s", upload.ID, err.Error()))
+				continue
+			}
+			report.DeletedObjects += len(orphan.Keys)
+			deleted++
+		}
+
+		if len(uploads) < uploadGCBatchSize {
+			break
+		}
+		// Deleted rows no longer shift into the next page
+		offset += len(uploads) - deleted
+	}
+
+	return report, nil
+}
+
+// findOrphan returns the objects to delete for upload, or nil if an item still references it
+func (s *UploadService) findOrphan(ctx context.Context, upload *models.Upload) (*models.OrphanedUpload, error) {
+	orphan := &models.OrphanedUpload{
+		UploadID:  upload.ID,
+		UserID:    upload.UserID,
+		Status:    upload.Status,
+		URL:       upload.URL,
+		CreatedAt: upload.CreatedAt,
+	}
+
+	if upload.Status != UploadStatusCompleted || upload.URL == nil {
+		// Never completed: only the raw upload, if any, is left behind
+		if upload.ObjectKey != "" {
+			orphan.Keys = []string{upload.ObjectKey}
+		}
+		return orphan, nil
+	}
+
+	urls := make([]string, 0, len(utils.ImageVariants))
+	for _, variant := range utils.ImageVariants {
+		url := utils.VariantURL(*upload.URL, variant.Name)
+		urls = append(urls, url)
+		if key, ok := storage.KeyFromURL(s.store, url); ok {
+			orphan.Keys = append(orphan.Keys, key)
+		}
+	}
+
+	referenced, err := isImageReferenced(ctx, urls)
+	if err != nil {
+		return nil, err
+	}
+	if referenced {
+		return nil, nil
+	}
+
+	return orphan, nil
+}
+
+// deleteOrphan removes an orphan's objects, then its upload record
+func (s *UploadService) deleteOrphan(ctx context.Context, orphan *models.OrphanedUpload) error {
+	if len(orphan.Keys) > 0 {
+		if err := s.store.Delete(ctx, orphan.Keys...); err != nil {
+			return fmt.Errorf("failed to delete objects: %w", err)
+		}
+	}
+
+	_, _, err := database.GetClient().From("uploads").
+		Delete("", "").
+		Eq("id", orphan.UploadID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to delete upload record: %w", err)
+	}
+
+	return nil
+}
+
+// isImageReferenced reports whether any item's images include one of urls
+func isImageReferenced(ctx context.Context, urls []string) (bool, error) {
+	client := database.GetClient()
+
+	data, _, err := client.From("items").
+		Select("id", "exact", false).
+		Overlaps("images", urls).
+		Limit(1, "").
+		Execute()
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check item references: %w", err)
+	}
+
+	var items []models.Item
+	if err := json.Unmarshal(data, &items); err != nil {
+		return false, fmt.Errorf("failed to parse items: %w", err)
+	}
+
+	return len(items) > 0, nil
+}
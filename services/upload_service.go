@@ -0,0 +1,453 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"pesxchange-backend/config"
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+	"pesxchange-backend/storage"
+	"pesxchange-backend/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ItemImagesBucket   = "item-images"   // Storage bucket for item images
+	MaxRequestBodySize = 2 * 1024 * 1024 // App-wide request body limit, see main.go
+	MaxImageUploadSize = 5 * 1024 * 1024 // 5MB per image uploaded straight to object storage
+	MaxImageDimension  = 8192            // Maximum width/height in pixels
+	maxBase64Size      = 7000000         // ~5MB base64 encoded
+
+	// MaxProxiedImageSize is the largest image sent through the API itself (multipart forms and
+	// local storage uploads): it has to fit in one request body along with multipart overhead
+	MaxProxiedImageSize = MaxRequestBodySize - 64*1024
+
+	uploadIntentTTL = 15 * time.Minute // How long a signed upload URL stays valid
+	incomingPrefix  = "incoming/"      // Raw direct uploads awaiting verification
+)
+
+// Upload statuses
+const (
+	UploadStatusPending   = "pending"
+	UploadStatusCompleted = "completed"
+	UploadStatusFailed    = "failed"
+)
+
+// allowedImageTypes maps accepted image MIME types to file extensions
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	// Note: GIF removed for security - can contain executable code
+}
+
+type UploadService struct {
+	store storage.ObjectStore
+}
+
+func NewUploadService() *UploadService {
+	return &UploadService{
+		store: storage.GetStore(ItemImagesBucket),
+	}
+}
+
+// ImageExtension maps an accepted image MIME type to its file extension
+func ImageExtension(contentType string) (string, error) {
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		return "", fmt.Errorf("unsupported image type: %s", contentType)
+	}
+	return ext, nil
+}
+
+// MaxUploadSize is the largest image a signed upload URL accepts. With the local backend
+// the upload goes through the API, so it is held to MaxProxiedImageSize.
+func (s *UploadService) MaxUploadSize() int64 {
+	if _, ok := s.store.(*storage.LocalStore); ok {
+		return MaxProxiedImageSize
+	}
+	return MaxImageUploadSize
+}
+
+// CreateIntent records a pending upload and returns a signed URL the client uploads the image to directly
+func (s *UploadService) CreateIntent(ctx context.Context, userID string, req *models.CreateUploadIntentRequest) (*models.UploadIntent, error) {
+	client := database.GetClient()
+
+	ext, err := ImageExtension(req.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported image type")
+	}
+	if req.Size <= 0 {
+		return nil, fmt.Errorf("invalid file size")
+	}
+	if req.Size > s.MaxUploadSize() {
+		return nil, fmt.Errorf("file too large")
+	}
+	if err := s.CheckQuota(ctx, userID, req.Size, 1); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	upload := &models.Upload{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Bucket:      ItemImagesBucket,
+		ContentType: req.ContentType,
+		Size:        req.Size,
+		Status:      UploadStatusPending,
+		ExpiresAt:   now.Add(uploadIntentTTL),
+		CreatedAt:   now,
+	}
+	upload.ObjectKey = fmt.Sprintf("%s%s/%s%s", incomingPrefix, userID, upload.ID, ext)
+
+	target, err := s.store.SignedUploadURL(ctx, upload.ObjectKey, upload.ContentType, upload.Size, uploadIntentTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign upload URL: %w", err)
+	}
+
+	_, _, err = client.From("uploads").
+		Insert(upload, false, "", "", "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	return &models.UploadIntent{
+		UploadID:  upload.ID,
+		UploadURL: target.URL,
+		Method:    target.Method,
+		Headers:   target.Headers,
+		ExpiresAt: upload.ExpiresAt,
+	}, nil
+}
+
+// CompleteUpload verifies a direct upload's size and magic bytes, then processes it into the
+// usual variants. Only the returned URLs can be attached to items; the raw upload is deleted.
+func (s *UploadService) CompleteUpload(ctx context.Context, userID, uploadID string) (map[string]string, error) {
+	upload, err := s.getUpload(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.UserID != userID {
+		return nil, fmt.Errorf("unauthorized: not the upload owner")
+	}
+
+	switch upload.Status {
+	case UploadStatusCompleted:
+		// Completing twice returns the same URLs
+		return variantURLs(*upload.URL), nil
+	case UploadStatusFailed:
+		return nil, fmt.Errorf("upload failed verification")
+	}
+
+	obj, err := s.store.Get(ctx, upload.ObjectKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("upload not received")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	// Backends can't all enforce the signed constraints, so check them again here
+	if reason := verifyUploadedImage(upload, obj.Data); reason != "" {
+		s.failUpload(ctx, upload, reason)
+		return nil, fmt.Errorf("upload failed verification: %s", reason)
+	}
+
+	baseName := fmt.Sprintf("%s_%d", uuid.New().String(), time.Now().Unix())
+	stored, err := StoreProcessedImage(ctx, s.store, baseName, obj.Data)
+	if err != nil {
+		// Undecodable, oversized or banned images are rejected; storage errors leave the upload pending for a retry
+		if strings.HasPrefix(err.Error(), "processing failed") || err.Error() == "image is banned" {
+			s.failUpload(ctx, upload, err.Error())
+			return nil, fmt.Errorf("upload failed verification: %s", err.Error())
+		}
+		return nil, err
+	}
+
+	if err := s.store.Delete(ctx, upload.ObjectKey); err != nil {
+		log.Printf("Failed to delete raw upload %s: %v", upload.ObjectKey, err)
+	}
+
+	now := time.Now()
+	fullURL := stored.URLs[utils.VariantFull]
+	_, _, err = database.GetClient().From("uploads").
+		Update(map[string]interface{}{
+			"status":       UploadStatusCompleted,
+			"url":          fullURL,
+			"phash":        utils.FormatHash(stored.PHash),
+			"phash_bands":  utils.HashBands(stored.PHash),
+			"width":        stored.Width,
+			"height":       stored.Height,
+			"blurhash":     stored.Blurhash,
+			"stored_bytes": stored.Bytes,
+			"completed_at": now,
+		}, "", "").
+		Eq("id", upload.ID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete upload: %w", err)
+	}
+
+	return stored.URLs, nil
+}
+
+// RecordUpload tracks an image uploaded through the API so garbage collection can find it
+func (s *UploadService) RecordUpload(ctx context.Context, userID string, stored *StoredImage, size int64) error {
+	fullURL := stored.URLs[utils.VariantFull]
+	key, _ := storage.KeyFromURL(s.store, fullURL)
+	phash := utils.FormatHash(stored.PHash)
+
+	now := time.Now()
+	upload := &models.Upload{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Bucket:      ItemImagesBucket,
+		ObjectKey:   key,
+		ContentType: utils.ProcessedImageContentType,
+		Size:        size,
+		Status:      UploadStatusCompleted,
+		URL:         &fullURL,
+		PHash:       &phash,
+		PHashBands:  utils.HashBands(stored.PHash),
+		Width:       &stored.Width,
+		Height:      &stored.Height,
+		Blurhash:    &stored.Blurhash,
+		StoredBytes: stored.Bytes,
+		ExpiresAt:   now,
+		CompletedAt: &now,
+		CreatedAt:   now,
+	}
+
+	_, _, err := database.GetClient().From("uploads").
+		Insert(upload, false, "", "", "").
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to record upload: %w", err)
+	}
+
+	return nil
+}
+
+// ConvertBase64Image stores an inline data:image URL as processed variants owned by userID.
+// Errors are short reasons suitable for per-image rejection messages.
+func (s *UploadService) ConvertBase64Image(ctx context.Context, userID, baseName, dataURL string) (map[string]string, error) {
+	// Parse base64 image
+	parts := strings.Split(dataURL, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid format")
+	}
+
+	// SECURITY: Check base64 string size before decoding
+	if len(parts[1]) > maxBase64Size {
+		return nil, fmt.Errorf("exceeds size limit")
+	}
+
+	// Decode base64
+	imageData, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("decode failed")
+	}
+
+	// SECURITY: Validate decoded size
+	if len(imageData) > MaxImageUploadSize {
+		return nil, fmt.Errorf("decoded size exceeds 5MB")
+	}
+
+	// SECURITY: Validate image using magic bytes
+	if _, err := ImageExtension(http.DetectContentType(imageData)); err != nil {
+		return nil, fmt.Errorf("invalid image type")
+	}
+
+	stored, err := StoreProcessedImage(ctx, s.store, baseName, imageData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Track the upload so it can be garbage collected if never attached to an item
+	if err := s.RecordUpload(ctx, userID, stored, int64(len(imageData))); err != nil {
+		log.Printf("Failed to track upload: %v", err)
+	}
+
+	return stored.URLs, nil
+}
+
+// CheckAttachable rejects image URLs that can't go on an item: raw uploads that haven't been
+// completed, URLs outside item image storage and inline data URLs that don't hold an image
+func (s *UploadService) CheckAttachable(urls []string) error {
+	for _, url := range urls {
+		if strings.HasPrefix(url, "data:") {
+			if _, err := decodeImageDataURL(url); err != nil {
+				return fmt.Errorf("invalid image")
+			}
+			continue
+		}
+
+		key, ok := storage.KeyFromURL(s.store, url)
+		if !ok {
+			return fmt.Errorf("invalid image")
+		}
+		if strings.HasPrefix(key, incomingPrefix) {
+			return fmt.Errorf("unverified image upload")
+		}
+	}
+	return nil
+}
+
+// decodeImageDataURL decodes a data:image URL, accepting it only if its bytes are one of
+// the allowed image types whatever type it declares
+func decodeImageDataURL(dataURL string) ([]byte, error) {
+	if !strings.HasPrefix(dataURL, "data:image/") {
+		return nil, fmt.Errorf("invalid image data")
+	}
+
+	parts := strings.SplitN(dataURL, ",", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid image data")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid image data")
+	}
+
+	if _, err := ImageExtension(http.DetectContentType(data)); err != nil {
+		return nil, fmt.Errorf("invalid image type")
+	}
+
+	return data, nil
+}
+
+// StoredImage is an image processed into variants and uploaded to storage
+type StoredImage struct {
+	URLs     map[string]string // Public URL of each variant, keyed by variant name
+	Width    int
+	Height   int
+	PHash    uint64 // Perceptual hash, see utils.PerceptualHash
+	Blurhash string // Placeholder, see utils.Blurhash
+	Bytes    int64  // Total size of all variants
+}
+
+// StoreProcessedImage runs an image through utils.ProcessImage and uploads every variant.
+// Images matching a banned hash are rejected when BLOCK_BANNED_IMAGES is enabled.
+func StoreProcessedImage(ctx context.Context, store storage.ObjectStore, baseName string, data []byte) (*StoredImage, error) {
+	processed, err := utils.ProcessImage(data, MaxImageDimension)
+	if err != nil {
+		return nil, fmt.Errorf("processing failed: %w", err)
+	}
+
+	if config.Load().BlockBannedImages {
+		banned, err := isBannedHash(ctx, processed.PHash)
+		if err != nil {
+			return nil, err
+		}
+		if banned {
+			return nil, fmt.Errorf("image is banned")
+		}
+	}
+
+	stored := &StoredImage{
+		URLs:     make(map[string]string, len(processed.Variants)),
+		Width:    processed.Width,
+		Height:   processed.Height,
+		PHash:    processed.PHash,
+		Blurhash: processed.Blurhash,
+	}
+	for _, variant := range utils.ImageVariants {
+		filename := utils.VariantFilename(baseName, variant.Name)
+
+		err := store.Put(ctx, filename, processed.Variants[variant.Name], utils.ProcessedImageContentType)
+		if err != nil {
+			return nil, fmt.Errorf("upload failed: %w", err)
+		}
+
+		stored.URLs[variant.Name] = store.PublicURL(filename)
+		stored.Bytes += int64(len(processed.Variants[variant.Name]))
+	}
+
+	return stored, nil
+}
+
+// verifyUploadedImage checks an uploaded object against its intent, returning why it was rejected
+func verifyUploadedImage(upload *models.Upload, data []byte) string {
+	size := int64(len(data))
+	if size > MaxImageUploadSize {
+		return "file too large"
+	}
+	if size != upload.Size {
+		return fmt.Sprintf("expected %d bytes, received %d", upload.Size, size)
+	}
+
+	// SECURITY: Validate file type using magic bytes, not the client's content type
+	contentType := http.DetectContentType(data)
+	if _, err := ImageExtension(contentType); err != nil {
+		return "invalid image type"
+	}
+	if contentType != upload.ContentType {
+		return fmt.Sprintf("expected %s, received %s", upload.ContentType, contentType)
+	}
+
+	return ""
+}
+
+// failUpload marks an upload as failed and removes the rejected object
+func (s *UploadService) failUpload(ctx context.Context, upload *models.Upload, reason string) {
+	if err := s.store.Delete(ctx, upload.ObjectKey); err != nil {
+		log.Printf("Failed to delete rejected upload %s: %v", upload.ObjectKey, err)
+	}
+
+	_, _, err := database.GetClient().From("uploads").
+		Update(map[string]interface{}{"status": UploadStatusFailed}, "", "").
+		Eq("id", upload.ID).
+		Execute()
+
+	if err != nil {
+		log.Printf("Failed to mark upload %s as failed (%s): %v", upload.ID, reason, err)
+	}
+}
+
+func (s *UploadService) getUpload(ctx context.Context, uploadID string) (*models.Upload, error) {
+	client := database.GetClient()
+
+	var uploads []models.Upload
+	data, _, err := client.From("uploads").
+		Select("*", "exact", false).
+		Eq("id", uploadID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &uploads); err != nil {
+		return nil, fmt.Errorf("failed to parse upload: %w", err)
+	}
+
+	if len(uploads) == 0 {
+		return nil, fmt.Errorf("upload not found")
+	}
+
+	return &uploads[0], nil
+}
+
+// variantURLs derives every variant URL from the URL of one processed variant
+func variantURLs(url string) map[string]string {
+	urls := make(map[string]string, len(utils.ImageVariants))
+	for _, variant := range utils.ImageVariants {
+		urls[variant.Name] = utils.VariantURL(url, variant.Name)
+	}
+	return urls
+}
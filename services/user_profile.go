@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+)
+
+// DefaultProfileVisibility applies to users who haven't changed their settings
+var DefaultProfileVisibility = models.ProfileVisibility{
+	ShowName: true,
+}
+
+// GetPublicProfile returns the profile other users see, with listing and follower stats
+func (s *UserService) GetPublicProfile(ctx context.Context, userID string) (*models.PublicProfile, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := PublicProfile(user)
+	profile.Vacation = VacationOf(user)
+
+	stats, err := s.getListingStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	profile.Stats = *stats
+
+	profile.Stats.Followers, profile.Stats.Following, err = getFollowCounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// UpdateProfileVisibility changes which optional fields appear on the user's public profile
+func (s *UserService) UpdateProfileVisibility(ctx context.Context, userID string, req *models.UpdateProfileVisibilityRequest) (*models.ProfileVisibility, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	visibility := ProfileVisibilityOf(user)
+	if req.ShowName != nil {
+		visibility.ShowName = *req.ShowName
+	}
+	if req.ShowBranch != nil {
+		visibility.ShowBranch = *req.ShowBranch
+	}
+	if req.ShowSemester != nil {
+		visibility.ShowSemester = *req.ShowSemester
+	}
+	if req.ShowCampus != nil {
+		visibility.ShowCampus = *req.ShowCampus
+	}
+
+	_, _, err = database.GetClient().From("user_profiles").
+		Update(map[string]interface{}{"profile_visibility": visibility}, "", "").
+		Eq("id", userID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update profile visibility: %w", err)
+	}
+
+	return &visibility, nil
+}
+
+// ProfileVisibilityOf returns a user's visibility settings, falling back to the defaults
+func ProfileVisibilityOf(user *models.User) models.ProfileVisibility {
+	if user.ProfileVisibility == nil {
+		return DefaultProfileVisibility
+	}
+	return *user.ProfileVisibility
+}
+
+// PublicProfile projects a user onto the fields other users may see. Stats are left empty.
+func PublicProfile(user *models.User) *models.PublicProfile {
+	visibility := ProfileVisibilityOf(user)
+
+	profile := &models.PublicProfile{
+		ID:            user.ID,
+		Nickname:      user.Nickname,
+		AvatarURL:     user.AvatarURL,
+		Bio:           user.Bio,
+		Rating:        user.Rating,
+		ReviewCount:   user.ReviewCount,
+		Verified:      user.Verified,
+		TrustedSeller: user.TrustedSeller,
+		Alumni:        user.AlumniSince != nil,
+		Location:      user.Location,
+		MemberSince:   user.CreatedAt,
+	}
+	if user.Handle != nil {
+		profile.Handle = *user.Handle
+	}
+	if visibility.ShowName {
+		profile.Name = user.Name
+	}
+	if visibility.ShowBranch {
+		profile.Branch = user.Branch
+	}
+	if visibility.ShowSemester {
+		profile.Semester = user.Semester
+	}
+	if visibility.ShowCampus {
+		profile.Campus = user.Campus
+	}
+
+	return profile
+}
+
+// RedactUser strips a user loaded for display alongside other content (such as an item's
+// seller) down to what their visibility settings allow
+func RedactUser(user *models.User) {
+	if !ProfileVisibilityOf(user).ShowName {
+		user.Name = ""
+	}
+	user.ProfileVisibility = nil
+}
+
+// getListingStats counts a user's listings
+func (s *UserService) getListingStats(ctx context.Context, userID string) (*models.ProfileStats, error) {
+	client := database.GetClient()
+
+	_, total, err := client.From("items").
+		Select("id", "exact", false).
+		Eq("seller_id", userID).
+		Limit(1, "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to count listings: %w", err)
+	}
+
+	_, active, err := client.From("items").
+		Select("id", "exact", false).
+		Eq("seller_id", userID).
+		Eq("is_available", "true").
+		Limit(1, "").
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to count listings: %w", err)
+	}
+
+	return &models.ProfileStats{
+		ActiveListings: int(active),
+		TotalListings:  int(total),
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"pesxchange-backend/database"
@@ -37,12 +38,30 @@ func (s *UserService) UpsertUser(ctx context.Context, profile *models.PESUProfil
 		return nil, fmt.Errorf("failed to parse existing user: %w", err)
 	}
 	
+	// SRNs can change (e.g. moving from UG to PG) but the PRN doesn't, so a new SRN is linked
+	// to the account already registered under the student's PRN
+	if len(existingUsers) == 0 {
+		linkedUser, err := s.getUserByPRN(ctx, profile.PRN)
+		if err != nil {
+			return nil, err
+		}
+		if linkedUser != nil {
+			existingUsers = append(existingUsers, *linkedUser)
+		}
+	}
+	
 	now := time.Now()
+	graduated := IsGraduated(profile, now)
 	
 	// If user exists, update their information
 	if len(existingUsers) > 0 {
 		existingUser := existingUsers[0]
 		
+		// Anonymised accounts can't be logged into again
+		if existingUser.DeletedAt != nil {
+			return nil, fmt.Errorf("account deleted")
+		}
+		
 		// Update with latest profile information
 		updatedUser := &models.User{
 			ID:          existingUser.ID, // Keep existing ID
@@ -66,6 +85,21 @@ func (s *UserService) UpsertUser(ctx context.Context, profile *models.PESUProfil
 			UpdatedAt:   now,
 			LastLogin:   &now,
 			Nickname:    existingUser.Nickname,    // Keep existing nickname
+
+			TrustedSeller:     existingUser.TrustedSeller,     // Keep trusted status
+			ProfileVisibility: existingUser.ProfileVisibility, // Keep privacy settings
+			Handle:            existingUser.Handle,            // Keep handle
+			HandleChangedAt:   existingUser.HandleChangedAt,
+			ReviewCount:       existingUser.ReviewCount, // Keep review count alongside rating
+
+			DeletionRequestedAt:  existingUser.DeletionRequestedAt, // Logging in doesn't cancel a deletion request
+			DeletionScheduledFor: existingUser.DeletionScheduledFor,
+
+			VacationStartedAt: existingUser.VacationStartedAt, // Keep vacation mode
+			VacationUntil:     existingUser.VacationUntil,
+			VacationMessage:   existingUser.VacationMessage,
+
+			AlumniSince: alumniSince(&existingUser, graduated, now),
 		}
 		
 		// Update the user in the database
@@ -78,6 +112,8 @@ func (s *UserService) UpsertUser(ctx context.Context, profile *models.PESUProfil
 			return nil, fmt.Errorf("failed to update user: %w", err)
 		}
 		
+		s.recordProfileChanges(ctx, &existingUser, updatedUser)
+		
 		return updatedUser, nil
 	}
 	
@@ -104,6 +140,7 @@ func (s *UserService) UpsertUser(ctx context.Context, profile *models.PESUProfil
 		UpdatedAt:   now,
 		LastLogin:   &now,
 		Nickname:    "", // Empty nickname initially
+		AlumniSince: alumniSince(nil, graduated, now),
 	}
 	
 	// Insert new user into database
@@ -190,11 +227,42 @@ func (s *UserService) CheckSRNExists(ctx context.Context, srn string) (bool, err
 	return len(users) > 0, nil
 }
 
-// UpdateUserProfile updates user profile information
-func (s *UserService) UpdateUserProfile(ctx context.Context, userID string, updates map[string]interface{}) (*models.User, error) {
+// UpdateUserProfile updates the editable profile fields set in req
+func (s *UserService) UpdateUserProfile(ctx context.Context, userID string, req *models.UpdateProfileRequest) (*models.User, error) {
 	client := database.GetClient()
 	
-	updates["updated_at"] = time.Now()
+	updates := map[string]interface{}{
+		"updated_at": time.Now(),
+	}
+	if req.Nickname != nil {
+		updates["nickname"] = strings.TrimSpace(*req.Nickname)
+	}
+	if req.Bio != nil {
+		updates["bio"] = strings.TrimSpace(*req.Bio)
+	}
+	if req.Location != nil {
+		updates["location"] = *req.Location
+	}
+	if req.AvatarURL != nil {
+		// Avatars must be the user's own uploaded avatars, so profiles can't hotlink or track
+		// viewers, or point at item images that are deleted along with their listing
+		if *req.AvatarURL != "" && !isOwnAvatarURL(userID, *req.AvatarURL) {
+			return nil, fmt.Errorf("invalid avatar URL")
+		}
+		updates["avatar_url"] = *req.AvatarURL
+	}
+	
+	// An uploaded avatar being replaced is deleted once the update succeeds
+	previousAvatarURL := ""
+	if req.AvatarURL != nil {
+		user, err := s.GetUserByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if user.AvatarURL != *req.AvatarURL {
+			previousAvatarURL = user.AvatarURL
+		}
+	}
 	
 	var updatedUsers []models.User
 	data, _, err := client.From("user_profiles").
@@ -214,5 +282,6 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, userID string, upda
 		return nil, fmt.Errorf("user not found")
 	}
 	
+	deleteAvatar(ctx, previousAvatarURL)
 	return &updatedUsers[0], nil
-}
\ No newline at end of file
+}
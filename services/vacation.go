@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"pesxchange-backend/database"
+	"pesxchange-backend/models"
+)
+
+const MaxVacationDays = 180 // Furthest ahead a return date can be set
+
+// StartVacation puts a user in vacation mode, hiding their listings until it ends. Calling it
+// while already away changes the return date and message without resetting the
+// once-per-conversation auto-replies.
+func (s *UserService) StartVacation(ctx context.Context, userID string, req *models.SetVacationRequest) (*models.User, error) {
+	now := time.Now()
+	if req.Until != nil {
+		if !req.Until.After(now) {
+			return nil, fmt.Errorf("return date must be in the future")
+		}
+		if req.Until.After(now.AddDate(0, 0, MaxVacationDays)) {
+			return nil, fmt.Errorf("return date too far ahead")
+		}
+	}
+
+	message := strings.TrimSpace(req.Message)
+	if message != "" && GetMessageScreener().Screen(message).Action == ScreeningBlock {
+		return nil, fmt.Errorf("message blocked by screening")
+	}
+
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A vacation past its return date is over even if EndExpiredVacations hasn't ended it yet,
+	// so setting one then starts afresh
+	current := VacationOf(user)
+	startedAt := now
+	if current != nil {
+		startedAt = current.StartedAt
+	} else if err := clearAutoReplies(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.setVacation(ctx, userID, map[string]interface{}{
+		"vacation_started_at": startedAt,
+		"vacation_until":      req.Until,
+		"vacation_message":    message,
+		"updated_at":          now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if current == nil {
+		if err := setSellerAway(ctx, userID, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return updated, nil
+}
+
+// EndVacation takes a user out of vacation mode and lists their items again
+func (s *UserService) EndVacation(ctx context.Context, userID string) (*models.User, error) {
+	updated, err := s.setVacation(ctx, userID, map[string]interface{}{
+		"vacation_started_at": nil,
+		"vacation_until":      nil,
+		"vacation_message":    "",
+		"updated_at":          time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setSellerAway(ctx, userID, false); err != nil {
+		return nil, err
+	}
+
+	// The next vacation starts with fresh auto-replies
+	if err := clearAutoReplies(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// EndExpiredVacations ends every vacation whose return date has passed, returning how many
+// were ended
+func (s *UserService) EndExpiredVacations(ctx context.Context) (int, error) {
+	var users []models.User
+	data, _, err := database.GetClient().From("user_profiles").
+		Select("id", "exact", false).
+		Lte("vacation_until", time.Now().UTC().Format(time.RFC3339Nano)).
+		Execute()
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired vacations: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &users); err != nil {
+		return 0, fmt.Errorf("failed to parse users: %w", err)
+	}
+
+	for i, user := range users {
+		if _, err := s.EndVacation(ctx, user.ID); err != nil {
+			return i, err
+		}
+	}
+
+	return len(users), nil
+}
+
+// VacationOf returns a user's away banner, or nil if they aren't on vacation. A vacation
+// past its return date counts as over even before EndExpiredVacations gets to it.
+func VacationOf(user *models.User) *models.Vacation {
+	if user.VacationStartedAt == nil {
+		return nil
+	}
+	if user.VacationUntil != nil && !user.VacationUntil.After(time.Now()) {
+		return nil
+	}
+
+	return &models.Vacation{
+		StartedAt: *user.VacationStartedAt,
+		Until:     user.VacationUntil,
+		Message:   user.VacationMessage,
+	}
+}
+
+// awayReplyText is the auto-reply sent for a user on vacation
+func awayReplyText(vacation *models.Vacation) string {
+	if vacation.Message != "" {
+		return vacation.Message
+	}
+	if vacation.Until != nil {
+		return fmt.Sprintf("I'm away until %s and will reply when I'm back.", vacation.Until.Format("2 January"))
+	}
+	return "I'm away at the moment and will reply when I'm back."
+}
+
+func (s *UserService) setVacation(ctx context.Context, userID string, updates map[string]interface{}) (*models.User, error) {
+	var updatedUsers []models.User
+	data, _, err := database.GetClient().From("user_profiles").
+		Update(updates, "", "").
+		Eq("id", userID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update vacation mode: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &updatedUsers); err != nil {
+		return nil, fmt.Errorf("failed to parse updated user: %w", err)
+	}
+
+	if len(updatedUsers) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	return &updatedUsers[0], nil
+}
+
+// setSellerAway updates the seller_away flag on all of a seller's items
+func setSellerAway(ctx context.Context, sellerID string, away bool) error {
+	_, _, err := database.GetClient().From("items").
+		Update(map[string]interface{}{"seller_away": away}, "", "").
+		Eq("seller_id", sellerID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to update listings: %w", err)
+	}
+
+	return nil
+}
+
+// clearAutoReplies forgets which conversations have had a user's away reply
+func clearAutoReplies(ctx context.Context, userID string) error {
+	_, _, err := database.GetClient().From("vacation_auto_replies").
+		Delete("", "").
+		Eq("user_id", userID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to clear auto-replies: %w", err)
+	}
+
+	return nil
+}
+
+// getVacation loads a user's vacation state, nil if they aren't away
+func getVacation(ctx context.Context, userID string) (*models.Vacation, error) {
+	var users []models.User
+	data, _, err := database.GetClient().From("user_profiles").
+		Select("id,vacation_started_at,vacation_until,vacation_message", "exact", false).
+		Eq("id", userID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vacation: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse vacation: %w", err)
+	}
+
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	return VacationOf(&users[0]), nil
+}
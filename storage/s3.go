@@ -0,0 +1,399 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3Service        = "s3"
+	s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+	s3MaxDeleteBatch = 1000 // DeleteObjects limit
+	s3MaxPresign     = 7 * 24 * time.Hour
+)
+
+// S3Store stores objects in an S3-compatible bucket (AWS S3, Cloudflare R2, MinIO, ...)
+// using SigV4-signed requests
+type S3Store struct {
+	endpoint   *url.URL
+	region     string
+	accessKey  string
+	secretKey  string
+	bucket     string
+	publicURL  string // Optional CDN base URL; defaults to the bucket URL
+	pathStyle  bool   // https://endpoint/bucket/key instead of https://bucket.endpoint/key
+	httpClient *http.Client
+}
+
+// NewS3Store creates a store for bucket on an S3-compatible endpoint
+func NewS3Store(endpoint, region, accessKey, secretKey, bucket, publicURL string, pathStyle bool) *S3Store {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		u = &url.URL{Scheme: "https", Host: endpoint}
+	}
+
+	return &S3Store{
+		endpoint:   u,
+		region:     region,
+		accessKey:  accessKey,
+		secretKey:  secretKey,
+		bucket:     bucket,
+		publicURL:  publicURL,
+		pathStyle:  pathStyle,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Put uploads an object
+func (s *S3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key, nil), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.signRequest(req, data)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+// Get downloads an object
+func (s *S3Store) Get(ctx context.Context, key string) (*Object, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key, nil), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	s.signRequest(req, nil)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("download failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return readObject(resp)
+}
+
+// Delete removes objects in batches with DeleteObjects
+func (s *S3Store) Delete(ctx context.Context, keys ...string) error {
+	for start := 0; start < len(keys); start += s3MaxDeleteBatch {
+		end := start + s3MaxDeleteBatch
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if err := s.deleteBatch(ctx, keys[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *S3Store) deleteBatch(ctx context.Context, keys []string) error {
+	type object struct {
+		Key string `xml:"Key"`
+	}
+	type deleteRequest struct {
+		XMLName xml.Name `xml:"Delete"`
+		Quiet   bool     `xml:"Quiet"`
+		Objects []object `xml:"Object"`
+	}
+
+	body := deleteRequest{Quiet: true}
+	for _, key := range keys {
+		if err := validateKey(key); err != nil {
+			return err
+		}
+		body.Objects = append(body.Objects, object{Key: key})
+	}
+
+	payload, err := xml.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.bucketURL(url.Values{"delete": {""}}), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// DeleteObjects requires an integrity checksum of the body
+	sum := sha256.Sum256(payload)
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Set("x-amz-checksum-sha256", base64.StdEncoding.EncodeToString(sum[:]))
+	s.signRequest(req, payload)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+// PublicURL returns the object's URL on the configured public base URL, or on the bucket itself
+func (s *S3Store) PublicURL(key string) string {
+	if s.publicURL != "" {
+		return s.publicURL + "/" + key
+	}
+	return s.objectURL(key, nil)
+}
+
+// SignedURL returns a presigned GET URL
+func (s *S3Store) SignedURL(ctx context.Context, key string, expiresIn time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return s.presign(http.MethodGet, key, expiresIn, nil), nil
+}
+
+// SignedUploadURL returns a presigned PUT URL; the signed Content-Type and Content-Length
+// headers make S3 reject uploads of any other type or size
+func (s *S3Store) SignedUploadURL(ctx context.Context, key, contentType string, size int64, expiresIn time.Duration) (*UploadTarget, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{
+		"Content-Type":   contentType,
+		"Content-Length": strconv.FormatInt(size, 10),
+	}
+
+	return &UploadTarget{
+		URL:     s.presign(http.MethodPut, key, expiresIn, headers),
+		Method:  http.MethodPut,
+		Headers: headers,
+	}, nil
+}
+
+// presign builds a SigV4 query-string-authenticated URL; extra headers must be sent by the client as-is
+func (s *S3Store) presign(method, key string, expiresIn time.Duration, headers map[string]string) string {
+	if expiresIn > s3MaxPresign {
+		expiresIn = s3MaxPresign
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	u, _ := url.Parse(s.objectURL(key, nil))
+
+	signedHeaders := []string{"host"}
+	canonicalHeaders := map[string]string{"host": u.Host}
+	for name, value := range headers {
+		name = strings.ToLower(name)
+		signedHeaders = append(signedHeaders, name)
+		canonicalHeaders[name] = strings.TrimSpace(value)
+	}
+	sort.Strings(signedHeaders)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiresIn.Seconds())))
+	query.Set("X-Amz-SignedHeaders", strings.Join(signedHeaders, ";"))
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		canonicalQuery(query),
+		formatCanonicalHeaders(signedHeaders, canonicalHeaders),
+		strings.Join(signedHeaders, ";"),
+		s3UnsignedBody,
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(now, amzDate, scope, canonicalRequest))
+	u.RawQuery = canonicalQuery(query)
+	return u.String()
+}
+
+// signRequest adds SigV4 Authorization headers to req for the given body
+func (s *S3Store) signRequest(req *http.Request, body []byte) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	payloadHash := sha256.Sum256(body)
+	payloadHex := hex.EncodeToString(payloadHash[:])
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHex)
+
+	headers := map[string]string{"host": req.URL.Host}
+	signedHeaders := []string{"host"}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+			signedHeaders = append(signedHeaders, lower)
+		}
+	}
+	sort.Strings(signedHeaders)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		formatCanonicalHeaders(signedHeaders, headers),
+		strings.Join(signedHeaders, ";"),
+		payloadHex,
+	}, "\n")
+
+	signature := s.signature(now, amzDate, scope, canonicalRequest)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKey, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+func (s *S3Store) scope(t time.Time) string {
+	return fmt.Sprintf("%s/%s/%s/aws4_request", t.Format("20060102"), s.region, s3Service)
+}
+
+// signature derives the SigV4 signing key and signs the canonical request
+func (s *S3Store) signature(t time.Time, amzDate, scope, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hex.EncodeToString(hash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (s *S3Store) bucketURL(query url.Values) string {
+	u := *s.endpoint
+	if s.pathStyle {
+		u.Path = "/" + s.bucket + "/"
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = "/"
+	}
+	if query != nil {
+		u.RawQuery = canonicalQuery(query)
+	}
+	return u.String()
+}
+
+func (s *S3Store) objectURL(key string, query url.Values) string {
+	u := *s.endpoint
+	escapedKey := escapeKey(key)
+	if s.pathStyle {
+		u.Path = "/" + s.bucket + "/" + key
+		u.RawPath = "/" + s.bucket + "/" + escapedKey
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = "/" + key
+		u.RawPath = "/" + escapedKey
+	}
+	if query != nil {
+		u.RawQuery = canonicalQuery(query)
+	}
+	return u.String()
+}
+
+// escapeKey URI-encodes each path segment of a key as SigV4 requires
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = uriEncode(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// canonicalQuery sorts and encodes query parameters as SigV4 requires
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func formatCanonicalHeaders(names []string, values map[string]string) string {
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(values[name])
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// uriEncode percent-encodes everything except RFC 3986 unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
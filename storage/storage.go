@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"pesxchange-backend/config"
+)
+
+// Object is a stored file and its metadata
+type Object struct {
+	Data         []byte
+	ContentType  string
+	Size         int64
+	LastModified time.Time
+	ETag         string
+}
+
+// ObjectStore is a bucket of files in one of the supported storage backends
+type ObjectStore interface {
+	// Put stores data under key, replacing any existing object
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get fetches an object, returning ErrNotFound if it does not exist
+	Get(ctx context.Context, key string) (*Object, error)
+	// Delete removes objects; missing keys are ignored
+	Delete(ctx context.Context, keys ...string) error
+	// PublicURL returns the permanent URL of an object in a public bucket
+	PublicURL(key string) string
+	// SignedURL returns a URL granting temporary read access to an object
+	SignedURL(ctx context.Context, key string, expiresIn time.Duration) (string, error)
+	// SignedUploadURL returns a target the client can upload a single object to directly.
+	// Backends that can't bind contentType and size into the URL accept any upload, so the
+	// object must always be verified after the client reports completion.
+	SignedUploadURL(ctx context.Context, key, contentType string, size int64, expiresIn time.Duration) (*UploadTarget, error)
+}
+
+// UploadTarget describes how a client uploads an object directly to storage
+type UploadTarget struct {
+	URL     string
+	Method  string
+	Headers map[string]string // Headers the client must send unchanged
+}
+
+// Error definitions
+var (
+	ErrNotFound            = fmt.Errorf("object not found")
+	ErrStoreNotInitialized = fmt.Errorf("object storage not initialized")
+)
+
+var (
+	cfg    *config.Config
+	mu     sync.Mutex
+	stores = make(map[string]ObjectStore)
+)
+
+// Initialize validates the storage configuration; buckets are opened lazily by GetStore
+func Initialize(c *config.Config) error {
+	switch c.StorageBackend {
+	case "supabase":
+		if c.SupabaseServiceKey == "" {
+			log.Println("Warning: SUPABASE_SERVICE_KEY not set, storage uploads will use the anon key")
+		}
+	case "local":
+		if c.StorageLocalDir == "" {
+			return fmt.Errorf("STORAGE_LOCAL_DIR is required for local storage")
+		}
+	case "s3":
+		if c.S3Endpoint == "" || c.S3AccessKeyID == "" || c.S3SecretAccessKey == "" {
+			return fmt.Errorf("S3_ENDPOINT, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required for s3 storage")
+		}
+	default:
+		return fmt.Errorf("unknown STORAGE_BACKEND %q", c.StorageBackend)
+	}
+
+	mu.Lock()
+	cfg = c
+	stores = make(map[string]ObjectStore)
+	mu.Unlock()
+
+	// Only log in development
+	if c.IsDevelopment() {
+		log.Printf("Object storage initialized (%s)", c.StorageBackend)
+	}
+	return nil
+}
+
+// GetStore returns the object store for a bucket
+func GetStore(bucket string) ObjectStore {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if store, ok := stores[bucket]; ok {
+		return store
+	}
+
+	if cfg == nil {
+		return &unavailableStore{}
+	}
+
+	var store ObjectStore
+	switch cfg.StorageBackend {
+	case "local":
+		store = NewLocalStore(cfg.StorageLocalDir, cfg.StoragePublicURL, bucket, cfg.JWTSecret)
+	case "s3":
+		store = NewS3Store(cfg.S3Endpoint, cfg.S3Region, cfg.S3AccessKeyID, cfg.S3SecretAccessKey, bucket, cfg.S3PublicURL, cfg.S3ForcePathStyle)
+	default:
+		key := cfg.SupabaseServiceKey
+		if key == "" {
+			key = cfg.SupabaseAnonKey
+		}
+		store = NewSupabaseStore(cfg.SupabaseURL, key, bucket)
+	}
+
+	stores[bucket] = store
+	return store
+}
+
+// KeyFromURL extracts the object key from a URL produced by store.PublicURL,
+// returning false if the URL does not belong to the store
+func KeyFromURL(store ObjectStore, url string) (string, bool) {
+	prefix := store.PublicURL("")
+	if prefix == "" || !strings.HasPrefix(url, prefix) {
+		return "", false
+	}
+
+	key := strings.TrimPrefix(url, prefix)
+	if i := strings.IndexAny(key, "?#"); i >= 0 {
+		key = key[:i]
+	}
+	if key == "" {
+		return "", false
+	}
+	return key, true
+}
+
+// IsValidBucketName reports whether name is a single safe path segment
+func IsValidBucketName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
+
+// validateKey rejects keys that could escape the bucket
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid object key")
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid object key")
+		}
+	}
+	return nil
+}
+
+// unavailableStore is returned before Initialize so callers fail cleanly instead of panicking
+type unavailableStore struct{}
+
+func (s *unavailableStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	return ErrStoreNotInitialized
+}
+
+func (s *unavailableStore) Get(ctx context.Context, key string) (*Object, error) {
+	return nil, ErrStoreNotInitialized
+}
+
+func (s *unavailableStore) Delete(ctx context.Context, keys ...string) error {
+	return ErrStoreNotInitialized
+}
+
+func (s *unavailableStore) PublicURL(key string) string {
+	return ""
+}
+
+func (s *unavailableStore) SignedURL(ctx context.Context, key string, expiresIn time.Duration) (string, error) {
+	return "", ErrStoreNotInitialized
+}
+
+func (s *unavailableStore) SignedUploadURL(ctx context.Context, key, contentType string, size int64, expiresIn time.Duration) (*UploadTarget, error) {
+	return nil, ErrStoreNotInitialized
+}
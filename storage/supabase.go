@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SupabaseStore stores objects in a Supabase Storage bucket
+type SupabaseStore struct {
+	baseURL    string // <SUPABASE_URL>/storage/v1
+	apiKey     string
+	bucket     string
+	httpClient *http.Client
+}
+
+// NewSupabaseStore creates a store for bucket using the given project URL and API key
+func NewSupabaseStore(supabaseURL, apiKey, bucket string) *SupabaseStore {
+	return &SupabaseStore{
+		baseURL:    strings.TrimRight(supabaseURL, "/") + "/storage/v1",
+		apiKey:     apiKey,
+		bucket:     bucket,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Put uploads an object with an explicit content type.
+// The SDK's UploadFile doesn't set content-type correctly, so the multipart request is built here.
+func (s *SupabaseStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	// Create multipart form
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	// Create form file header with explicit content-type
+	h := make(map[string][]string)
+	h["Content-Disposition"] = []string{fmt.Sprintf(`form-data; name="file"; filename="%s"`, key)}
+	h["Content-Type"] = []string{contentType}
+
+	part, err := writer.CreatePart(h)
+	if err != nil {
+		return fmt.Errorf("failed to create form part: %w", err)
+	}
+
+	if _, err := part.Write(data); err != nil {
+		return fmt.Errorf("failed to write file data: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPost, s.objectURL(key), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+// Get downloads an object
+func (s *SupabaseStore) Get(ctx context.Context, key string) (*Object, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodGet, s.objectURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Supabase reports missing objects as 400 or 404 depending on version
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("download failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return readObject(resp)
+}
+
+// Delete removes objects from the bucket
+func (s *SupabaseStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		if err := validateKey(key); err != nil {
+			return err
+		}
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{"prefixes": keys})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodDelete, fmt.Sprintf("%s/object/%s", s.baseURL, s.bucket), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+// PublicURL returns the public URL of an object, matching the storage-go client's format
+func (s *SupabaseStore) PublicURL(key string) string {
+	return fmt.Sprintf("%s/object/public/%s/%s", s.baseURL, s.bucket, key)
+}
+
+// SignedURL asks Supabase to sign a temporary download URL
+func (s *SupabaseStore) SignedURL(ctx context.Context, key string, expiresIn time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{"expiresIn": int(expiresIn.Seconds())})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPost, fmt.Sprintf("%s/object/sign/%s/%s", s.baseURL, s.bucket, key), bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sign request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("sign failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var signed struct {
+		SignedURL string `json:"signedURL"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&signed); err != nil {
+		return "", fmt.Errorf("invalid sign response: %w", err)
+	}
+
+	return s.baseURL + signed.SignedURL, nil
+}
+
+// SignedUploadURL asks Supabase for a signed upload URL. Supabase only enforces the bucket's
+// own size and type limits, so contentType and size are checked when the upload is completed.
+func (s *SupabaseStore) SignedUploadURL(ctx context.Context, key, contentType string, size int64, expiresIn time.Duration) (*UploadTarget, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPost, fmt.Sprintf("%s/object/upload/sign/%s/%s", s.baseURL, s.bucket, key), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sign request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("sign failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var signed struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&signed); err != nil {
+		return nil, fmt.Errorf("invalid sign response: %w", err)
+	}
+
+	return &UploadTarget{
+		URL:     s.baseURL + signed.URL,
+		Method:  http.MethodPut,
+		Headers: map[string]string{"Content-Type": contentType},
+	}, nil
+}
+
+func (s *SupabaseStore) objectURL(key string) string {
+	return fmt.Sprintf("%s/object/%s/%s", s.baseURL, s.bucket, key)
+}
+
+// newRequest creates a request authenticated with the API key
+func (s *SupabaseStore) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.apiKey))
+	req.Header.Set("apikey", s.apiKey)
+	return req, nil
+}
+
+// readObject builds an Object from a successful HTTP download response
+func readObject(resp *http.Response) (*Object, error) {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+
+	obj := &Object{
+		Data:        data,
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        int64(len(data)),
+		ETag:        resp.Header.Get("ETag"),
+	}
+
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		obj.LastModified = modified
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"image"
+	"strings"
+	"unicode"
+)
+
+// Avatar variant names
+const (
+	AvatarSmall = "small"
+	AvatarLarge = "large"
+)
+
+// AvatarVariants lists the square sizes generated for each avatar, smallest first
+var AvatarVariants = []ImageVariant{
+	{Name: AvatarSmall, MaxDimension: 128},
+	{Name: AvatarLarge, MaxDimension: 512},
+}
+
+// Background colours for initials avatars, picked by user ID so each user keeps theirs
+var avatarColors = []string{
+	"#e57373", "#f06292", "#ba68c8", "#9575cd", "#7986cb", "#64b5f6",
+	"#4fc3f7", "#4dd0e1", "#4db6ac", "#81c784", "#aed581", "#ffb74d",
+}
+
+// ProcessedAvatar holds the re-encoded square variants of an uploaded avatar
+type ProcessedAvatar struct {
+	PHash    uint64            // Perceptual hash of the cropped image, see PerceptualHash
+	Variants map[string][]byte // Encoded bytes keyed by avatar variant name
+}
+
+// ProcessAvatar decodes an image the same way ProcessImage does, crops the centre square
+// and re-encodes it as a JPEG in every size from AvatarVariants
+func ProcessAvatar(data []byte, maxDimension int) (*ProcessedAvatar, error) {
+	base, err := decodeImage(data, maxDimension)
+	if err != nil {
+		return nil, err
+	}
+
+	square := cropToSquare(base)
+	processed := &ProcessedAvatar{
+		PHash:    PerceptualHash(square),
+		Variants: make(map[string][]byte, len(AvatarVariants)),
+	}
+
+	for _, variant := range AvatarVariants {
+		encoded, err := encodeVariant(square, variant)
+		if err != nil {
+			return nil, err
+		}
+		processed.Variants[variant.Name] = encoded
+	}
+
+	return processed, nil
+}
+
+// IsAvatarVariant reports whether name is one of AvatarVariants
+func IsAvatarVariant(name string) bool {
+	for _, v := range AvatarVariants {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cropToSquare returns the largest centred square of img
+func cropToSquare(img image.Image) image.Image {
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	rect := image.Rect(x, y, x+side, y+side)
+
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+	return img
+}
+
+// Initials returns up to two uppercase initials from the first words of name, or "?"
+func Initials(name string) string {
+	var initials []rune
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		initials = append(initials, unicode.ToUpper([]rune(word)[0]))
+		if len(initials) == 2 {
+			break
+		}
+	}
+	if len(initials) == 0 {
+		return "?"
+	}
+	return string(initials)
+}
+
+// InitialsAvatarSVG renders a square SVG avatar showing the initials of name on a
+// background colour derived from seed
+func InitialsAvatarSVG(name, seed string, size int) []byte {
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	color := avatarColors[h.Sum32()%uint32(len(avatarColors))]
+
+	return []byte(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="%[1]d" viewBox="0 0 100 100">`+
+			`<rect width="100" height="100" fill="%[2]s"/>`+
+			`<text x="50" y="50" dy=".35em" text-anchor="middle" font-family="sans-serif" font-size="40" fill="#ffffff">%[3]s</text>`+
+			`</svg>`,
+		size, color, html.EscapeString(Initials(name))))
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"image"
+	"math"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	blurhashComponentsX = 4
+	blurhashComponentsY = 3
+	blurhashSampleSize  = 32 // Images are reduced to at most 32px before encoding
+	base83Chars         = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+)
+
+// Blurhash encodes img as a BlurHash (https://blurha.sh), a short string clients decode
+// into a blurred placeholder while the real image loads
+func Blurhash(img image.Image) string {
+	small := resizeToFit(img, blurhashSampleSize)
+	bounds := small.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return ""
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(rgba, rgba.Bounds(), small, bounds.Min, draw.Src)
+
+	// Linearise once; every component reads every pixel
+	linear := make([][3]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := rgba.PixOffset(x, y)
+			linear[y*width+x] = [3]float64{
+				srgbToLinear(rgba.Pix[i]),
+				srgbToLinear(rgba.Pix[i+1]),
+				srgbToLinear(rgba.Pix[i+2]),
+			}
+		}
+	}
+
+	factors := make([][3]float64, 0, blurhashComponentsX*blurhashComponentsY)
+	for j := 0; j < blurhashComponentsY; j++ {
+		for i := 0; i < blurhashComponentsX; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1.0
+			}
+
+			var r, g, b float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := normalisation *
+						math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+					p := linear[y*width+x]
+					r += basis * p[0]
+					g += basis * p[1]
+					b += basis * p[2]
+				}
+			}
+
+			scale := 1.0 / float64(width*height)
+			factors = append(factors, [3]float64{r * scale, g * scale, b * scale})
+		}
+	}
+
+	var hash strings.Builder
+	sizeFlag := (blurhashComponentsX - 1) + (blurhashComponentsY-1)*9
+	hash.WriteString(encodeBase83(sizeFlag, 1))
+
+	dc, ac := factors[0], factors[1:]
+
+	var actualMax float64
+	for _, f := range ac {
+		for _, v := range f {
+			actualMax = math.Max(actualMax, math.Abs(v))
+		}
+	}
+	quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+	maxValue := float64(quantisedMax+1) / 166
+	hash.WriteString(encodeBase83(quantisedMax, 1))
+
+	dcValue := linearToSrgb(dc[0])<<16 + linearToSrgb(dc[1])<<8 + linearToSrgb(dc[2])
+	hash.WriteString(encodeBase83(dcValue, 4))
+
+	for _, f := range ac {
+		quant := func(v float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maxValue, 0.5)*9+9.5))))
+		}
+		hash.WriteString(encodeBase83(quant(f[0])*19*19+quant(f[1])*19+quant(f[2]), 2))
+	}
+
+	return hash.String()
+}
+
+func encodeBase83(value, length int) string {
+	buf := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		buf[i-1] = base83Chars[digit]
+	}
+	return string(buf)
+}
+
+func srgbToLinear(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// EXIF orientation values (TIFF tag 0x0112)
+const (
+	orientationNormal     = 1
+	orientationFlipH      = 2
+	orientationRotate180  = 3
+	orientationFlipV      = 4
+	orientationTranspose  = 5
+	orientationRotate90   = 6
+	orientationTransverse = 7
+	orientationRotate270  = 8
+)
+
+const exifOrientationTag = 0x0112
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// ReadOrientation returns the EXIF orientation of a JPEG, PNG or WebP image,
+// or 1 (normal) when there is no EXIF data or it cannot be parsed
+func ReadOrientation(data []byte) int {
+	var tiff []byte
+
+	switch {
+	case len(data) > 2 && data[0] == 0xFF && data[1] == 0xD8:
+		tiff = jpegExif(data)
+	case len(data) > 8 && bytes.Equal(data[:8], []byte("\x89PNG\r\n\x1a\n")):
+		tiff = pngExif(data)
+	case len(data) > 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		tiff = webpExif(data)
+	}
+
+	if tiff == nil {
+		return orientationNormal
+	}
+
+	orientation := tiffOrientation(tiff)
+	if orientation < orientationNormal || orientation > orientationRotate270 {
+		return orientationNormal
+	}
+	return orientation
+}
+
+// jpegExif finds the TIFF payload of the APP1 Exif segment
+func jpegExif(data []byte) []byte {
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil
+		}
+		marker := data[pos+1]
+
+		// Start of scan: no more metadata segments
+		if marker == 0xDA || marker == 0xD9 {
+			return nil
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil
+		}
+		segment := data[pos+4 : pos+2+length]
+
+		if marker == 0xE1 && bytes.HasPrefix(segment, exifHeader) {
+			return segment[len(exifHeader):]
+		}
+
+		pos += 2 + length
+	}
+	return nil
+}
+
+// pngExif finds the TIFF payload of the eXIf chunk
+func pngExif(data []byte) []byte {
+	pos := 8
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		if length < 0 || pos+8+length > len(data) {
+			return nil
+		}
+
+		if chunkType == "eXIf" {
+			return data[pos+8 : pos+8+length]
+		}
+		if chunkType == "IDAT" || chunkType == "IEND" {
+			return nil
+		}
+
+		pos += 12 + length // length, type, data, CRC
+	}
+	return nil
+}
+
+// webpExif finds the TIFF payload of the EXIF chunk
+func webpExif(data []byte) []byte {
+	pos := 12
+	for pos+8 <= len(data) {
+		chunkType := string(data[pos : pos+4])
+		length := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		if length < 0 || pos+8+length > len(data) {
+			return nil
+		}
+
+		if chunkType == "EXIF" {
+			payload := data[pos+8 : pos+8+length]
+			// Some encoders keep the JPEG-style header
+			return bytes.TrimPrefix(payload, exifHeader)
+		}
+
+		pos += 8 + length + length%2 // Chunks are padded to even sizes
+	}
+	return nil
+}
+
+// tiffOrientation reads the orientation tag from IFD0 of a TIFF structure
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return orientationNormal
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return orientationNormal
+	}
+
+	if order.Uint16(tiff[2:4]) != 42 {
+		return orientationNormal
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return orientationNormal
+	}
+
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:entry+2]) == exifOrientationTag {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+
+	return orientationNormal
+}
+
+// applyOrientation transforms img so it displays upright for the given EXIF orientation
+func applyOrientation(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= orientationNormal || orientation > orientationRotate270 {
+		return img
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dstW, dstH := w, h
+	if orientation >= orientationTranspose {
+		dstW, dstH = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			// Source pixel for each destination pixel
+			var sx, sy int
+			switch orientation {
+			case orientationFlipH:
+				sx, sy = w-1-x, y
+			case orientationRotate180:
+				sx, sy = w-1-x, h-1-y
+			case orientationFlipV:
+				sx, sy = x, h-1-y
+			case orientationTranspose:
+				sx, sy = y, x
+			case orientationRotate90:
+				sx, sy = y, h-1-x
+			case orientationTransverse:
+				sx, sy = w-1-y, h-1-x
+			case orientationRotate270:
+				sx, sy = w-1-y, x
+			}
+
+			si := img.PixOffset(img.Rect.Min.X+sx, img.Rect.Min.Y+sy)
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], img.Pix[si:si+4])
+		}
+	}
+
+	return dst
+}
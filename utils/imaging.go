@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // Register PNG decoder
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Register WebP decoder
+)
+
+// ImageVariant describes one resized copy generated for every uploaded image
+type ImageVariant struct {
+	Name         string
+	MaxDimension int // Longest side in pixels
+}
+
+// Variant names
+const (
+	VariantThumb  = "thumb"
+	VariantMedium = "medium"
+	VariantFull   = "full"
+)
+
+// ImageVariants lists the variants generated for each upload, smallest first
+var ImageVariants = []ImageVariant{
+	{Name: VariantThumb, MaxDimension: 320},
+	{Name: VariantMedium, MaxDimension: 800},
+	{Name: VariantFull, MaxDimension: 1600},
+}
+
+const (
+	processedImageQuality     = 82 // JPEG quality used when re-encoding variants
+	ProcessedImageContentType = "image/jpeg"
+	ProcessedImageExtension   = ".jpg"
+)
+
+// ProcessedImage holds the re-encoded variants of an uploaded image
+type ProcessedImage struct {
+	Width    int               // Width of the original image, after orientation
+	Height   int               // Height of the original image, after orientation
+	PHash    uint64            // Perceptual hash of the original image, see PerceptualHash
+	Blurhash string            // Placeholder for clients, see Blurhash
+	Variants map[string][]byte // Encoded bytes keyed by variant name
+}
+
+// ProcessImage decodes a JPEG, PNG or WebP image, rejects it if either side exceeds
+// maxDimension, and re-encodes it as a JPEG in every size from ImageVariants.
+// Re-encoding drops all EXIF, XMP and ICC metadata (including GPS coordinates), so the
+// EXIF orientation is applied to the pixels first to keep the image upright.
+func ProcessImage(data []byte, maxDimension int) (*ProcessedImage, error) {
+	base, err := decodeImage(data, maxDimension)
+	if err != nil {
+		return nil, err
+	}
+
+	processed := &ProcessedImage{
+		Width:    base.Bounds().Dx(),
+		Height:   base.Bounds().Dy(),
+		PHash:    PerceptualHash(base),
+		Blurhash: Blurhash(base),
+		Variants: make(map[string][]byte, len(ImageVariants)),
+	}
+
+	for _, variant := range ImageVariants {
+		encoded, err := encodeVariant(base, variant)
+		if err != nil {
+			return nil, err
+		}
+		processed.Variants[variant.Name] = encoded
+	}
+
+	return processed, nil
+}
+
+// RenderVariant resizes and re-encodes an image as a single variant, the same way
+// ProcessImage does, for images stored before variants were generated
+func RenderVariant(data []byte, variant string, maxDimension int) ([]byte, error) {
+	for _, v := range ImageVariants {
+		if v.Name == variant {
+			base, err := decodeImage(data, maxDimension)
+			if err != nil {
+				return nil, err
+			}
+			return encodeVariant(base, v)
+		}
+	}
+	return nil, fmt.Errorf("unknown image variant: %s", variant)
+}
+
+// IsVariant reports whether name is one of ImageVariants
+func IsVariant(name string) bool {
+	for _, v := range ImageVariants {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeImage decodes a JPEG, PNG or WebP image no larger than maxDimension on either side,
+// flattened onto white and rotated upright
+func decodeImage(data []byte, maxDimension int) (image.Image, error) {
+	// Check dimensions from the header before decoding the whole image
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image header: %w", err)
+	}
+	if format != "jpeg" && format != "png" && format != "webp" {
+		return nil, fmt.Errorf("unsupported image format: %s", format)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, fmt.Errorf("invalid image dimensions")
+	}
+	if cfg.Width > maxDimension || cfg.Height > maxDimension {
+		return nil, fmt.Errorf("image dimensions %dx%d exceed %dpx limit", cfg.Width, cfg.Height, maxDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	// JPEG has no alpha channel, so flatten transparent images onto white, then rotate upright
+	return applyOrientation(flattenImage(img), ReadOrientation(data)), nil
+}
+
+// encodeVariant resizes img to fit variant and encodes it as a JPEG
+func encodeVariant(img image.Image, variant ImageVariant) ([]byte, error) {
+	resized := resizeToFit(img, variant.MaxDimension)
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, resized, &jpeg.Options{Quality: processedImageQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode %s variant: %w", variant.Name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// VariantFilename returns the storage filename of a variant for a base name
+func VariantFilename(baseName, variant string) string {
+	return fmt.Sprintf("%s_%s%s", baseName, variant, ProcessedImageExtension)
+}
+
+// VariantURL rewrites the URL of a processed image to point at another variant.
+// URLs of images uploaded before processing existed are returned unchanged.
+func VariantURL(url, variant string) string {
+	for _, v := range ImageVariants {
+		suffix := "_" + v.Name + ProcessedImageExtension
+		if strings.HasSuffix(url, suffix) {
+			return strings.TrimSuffix(url, suffix) + "_" + variant + ProcessedImageExtension
+		}
+	}
+	return url
+}
+
+// resizeToFit scales img down so its longest side is at most maxDimension, keeping the aspect ratio
+func resizeToFit(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width <= maxDimension && height <= maxDimension {
+		return img
+	}
+
+	newWidth, newHeight := maxDimension, maxDimension
+	if width >= height {
+		newHeight = height * maxDimension / width
+	} else {
+		newWidth = width * maxDimension / height
+	}
+	if newWidth < 1 {
+		newWidth = 1
+	}
+	if newHeight < 1 {
+		newHeight = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// flattenImage draws img onto an opaque white background
+func flattenImage(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"testing"
+)
+
+// The fixtures in testdata are 64x32 photos with a red block in the top-left corner and a
+// green block in the top-right corner when displayed upright. Each is stored rotated so that
+// its EXIF orientation tag is needed to display it upright, and carries GPS coordinates.
+const (
+	fixtureWidth  = 64
+	fixtureHeight = 32
+)
+
+func loadFixture(t *testing.T, orientation int) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(fmt.Sprintf("testdata/gps_orientation_%d.jpg", orientation))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	if !hasJPEGSegment(data, 0xE1) || !bytes.Contains(data, exifHeader) {
+		t.Fatalf("fixture has no EXIF segment")
+	}
+	if got := ReadOrientation(data); got != orientation {
+		t.Fatalf("fixture orientation = %d, want %d", got, orientation)
+	}
+	return data
+}
+
+// hasJPEGSegment reports whether a JPEG has a metadata segment with the given marker
+// before its image data
+func hasJPEGSegment(data []byte, marker byte) bool {
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xFF {
+		if data[pos+1] == 0xDA {
+			return false
+		}
+		if data[pos+1] == marker {
+			return true
+		}
+		pos += 2 + (int(data[pos+2])<<8 | int(data[pos+3]))
+	}
+	return false
+}
+
+func TestProcessImageStripsGPS(t *testing.T) {
+	for _, orientation := range []int{1, 3, 6, 8} {
+		t.Run(fmt.Sprintf("orientation %d", orientation), func(t *testing.T) {
+			processed, err := ProcessImage(loadFixture(t, orientation), 8192)
+			if err != nil {
+				t.Fatalf("ProcessImage: %v", err)
+			}
+
+			for _, variant := range ImageVariants {
+				out := processed.Variants[variant.Name]
+				if hasJPEGSegment(out, 0xE1) {
+					t.Errorf("%s variant has an APP1 (EXIF/XMP) segment", variant.Name)
+				}
+				if bytes.Contains(out, exifHeader) {
+					t.Errorf("%s variant contains EXIF data", variant.Name)
+				}
+				if ReadOrientation(out) != orientationNormal {
+					t.Errorf("%s variant still has an orientation tag", variant.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessImageAppliesOrientation(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	green := color.RGBA{0, 255, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	for _, orientation := range []int{1, 3, 6, 8} {
+		t.Run(fmt.Sprintf("orientation %d", orientation), func(t *testing.T) {
+			data := loadFixture(t, orientation)
+
+			// Rotated by 90 degrees either way, the stored pixels have width and height swapped
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("failed to read fixture header: %v", err)
+			}
+			storedW, storedH := fixtureWidth, fixtureHeight
+			if orientation == orientationRotate90 || orientation == orientationRotate270 {
+				storedW, storedH = fixtureHeight, fixtureWidth
+			}
+			if cfg.Width != storedW || cfg.Height != storedH {
+				t.Fatalf("fixture is %dx%d, want %dx%d", cfg.Width, cfg.Height, storedW, storedH)
+			}
+
+			processed, err := ProcessImage(data, 8192)
+			if err != nil {
+				t.Fatalf("ProcessImage: %v", err)
+			}
+			if processed.Width != fixtureWidth || processed.Height != fixtureHeight {
+				t.Errorf("processed size = %dx%d, want %dx%d", processed.Width, processed.Height, fixtureWidth, fixtureHeight)
+			}
+
+			img, err := jpeg.Decode(bytes.NewReader(processed.Variants[VariantFull]))
+			if err != nil {
+				t.Fatalf("failed to decode full variant: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != fixtureWidth || b.Dy() != fixtureHeight {
+				t.Fatalf("full variant is %dx%d, want %dx%d", b.Dx(), b.Dy(), fixtureWidth, fixtureHeight)
+			}
+
+			corners := []struct {
+				name string
+				at   image.Point
+				want color.RGBA
+			}{
+				{"top-left", image.Pt(4, 4), red},
+				{"top-right", image.Pt(fixtureWidth-5, 4), green},
+				{"bottom-left", image.Pt(4, fixtureHeight-5), blue},
+				{"bottom-right", image.Pt(fixtureWidth-5, fixtureHeight-5), blue},
+			}
+			for _, c := range corners {
+				if got := img.At(c.at.X, c.at.Y); !closeColor(got, c.want) {
+					t.Errorf("%s pixel = %v, want about %v", c.name, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+// closeColor compares colors loosely enough to allow for JPEG compression
+func closeColor(got color.Color, want color.RGBA) bool {
+	r, g, b, _ := got.RGBA()
+	near := func(v uint32, w uint8) bool {
+		d := int(v>>8) - int(w)
+		return d > -48 && d < 48
+	}
+	return near(r, want.R) && near(g, want.G) && near(b, want.B)
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	phashSampleSize = 32 // Images are reduced to 32x32 greyscale before the DCT
+	phashBlockSize  = 8  // The 8x8 lowest frequencies make up the 64-bit hash
+)
+
+// PerceptualHash computes a 64-bit DCT perceptual hash (pHash) of img. Visually similar
+// images - re-encoded, resized, lightly edited - have hashes a small Hamming distance apart.
+func PerceptualHash(img image.Image) uint64 {
+	small := image.NewRGBA(image.Rect(0, 0, phashSampleSize, phashSampleSize))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	pixels := make([][]float64, phashSampleSize)
+	for y := 0; y < phashSampleSize; y++ {
+		pixels[y] = make([]float64, phashSampleSize)
+		for x := 0; x < phashSampleSize; x++ {
+			i := small.PixOffset(x, y)
+			r, g, b := float64(small.Pix[i]), float64(small.Pix[i+1]), float64(small.Pix[i+2])
+			pixels[y][x] = 0.299*r + 0.587*g + 0.114*b
+		}
+	}
+
+	coeffs := dct2D(pixels)
+
+	// Compare the low frequencies against their median, skipping the DC term which only
+	// reflects overall brightness
+	lows := make([]float64, 0, phashBlockSize*phashBlockSize)
+	for y := 0; y < phashBlockSize; y++ {
+		for x := 0; x < phashBlockSize; x++ {
+			lows = append(lows, coeffs[y][x])
+		}
+	}
+	sorted := append([]float64(nil), lows[1:]...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	var hash uint64
+	for i, v := range lows {
+		if v > median {
+			hash |= 1 << uint(len(lows)-1-i)
+		}
+	}
+	return hash
+}
+
+// HashDistance returns the number of differing bits between two perceptual hashes
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// FormatHash encodes a perceptual hash as 16 hex digits
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// ParseHash decodes a hash produced by FormatHash
+func ParseHash(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// HashBands splits a hash into its eight bytes, each tagged with its position (position*256 + byte).
+// Hashes within Hamming distance 7 always share at least one band, so exact band matches
+// find near-duplicate candidates without comparing against every stored hash.
+func HashBands(hash uint64) []int {
+	bands := make([]int, 8)
+	for i := range bands {
+		bands[i] = i*256 + int(hash>>(56-8*uint(i))&0xff)
+	}
+	return bands
+}
+
+// dct2D applies a 2D type-II discrete cosine transform to a square matrix
+func dct2D(in [][]float64) [][]float64 {
+	n := len(in)
+
+	cos := make([][]float64, n)
+	for k := 0; k < n; k++ {
+		cos[k] = make([]float64, n)
+		for i := 0; i < n; i++ {
+			cos[k][i] = math.Cos(math.Pi / float64(n) * (float64(i) + 0.5) * float64(k))
+		}
+	}
+
+	// Rows, then columns
+	rows := make([][]float64, n)
+	for y := 0; y < n; y++ {
+		rows[y] = make([]float64, n)
+		for k := 0; k < n; k++ {
+			var sum float64
+			for x := 0; x < n; x++ {
+				sum += in[y][x] * cos[k][x]
+			}
+			rows[y][k] = sum
+		}
+	}
+
+	out := make([][]float64, n)
+	for k := 0; k < n; k++ {
+		out[k] = make([]float64, n)
+	}
+	for x := 0; x < n; x++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for y := 0; y < n; y++ {
+				sum += rows[y][x] * cos[k][y]
+			}
+			out[k][x] = sum
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ProfileLocations are the locations a user can pick for their profile. The first is the
+// default for new users.
+var ProfileLocations = []string{
+	"PES University, Bangalore",
+	"PES University, RR Campus",
+	"PES University, EC Campus",
+	"PES University, HN Campus",
+}
+
+// Letters, digits, spaces, underscores, periods and hyphens, starting with a letter or digit
+var nicknamePattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} _.\-]*$`)
+
+// NewValidator returns a validator with the app's custom tags registered:
+//   - nickname: see nicknamePattern
+//   - profile_location: one of ProfileLocations
+func NewValidator() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterValidation("nickname", func(fl validator.FieldLevel) bool {
+		return nicknamePattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("profile_location", func(fl validator.FieldLevel) bool {
+		return IsProfileLocation(fl.Field().String())
+	})
+
+	return v
+}
+
+// IsProfileLocation reports whether location is one of ProfileLocations
+func IsProfileLocation(location string) bool {
+	for _, l := range ProfileLocations {
+		if l == location {
+			return true
+		}
+	}
+	return false
+}